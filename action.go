@@ -15,6 +15,7 @@ const (
 	deprecatedMarker     = "*deprecated*"
 	actionBoolSuffix     = "Only literal 'true' will be treated as true."
 	actionSliceSuffix    = "Accepts multiple values. One value per line."
+	actionDefaultSuffix  = "Default: `%s` unless set in the config file."
 	compositeRunner      = "composite"
 	defaultShell         = "sh"
 	releaseTrainBinInput = "release-train-bin"
@@ -201,19 +202,9 @@ func (b *actionBuilder) processFlag(flag *kong.Flag) (string, *actionInput, stri
 		return "", nil, "", nil // Skip this flag
 	}
 
-	actionInputName := flag.Name
-	actionDefault := flag.Default
-	if tag.Has(actionTagName) {
-		input, def, hasDef := strings.Cut(tag.Get(actionTagName), ",")
-		if input == "-" {
-			return "", nil, "", nil // Explicitly skipped
-		}
-		if input != "" {
-			actionInputName = input
-		}
-		if hasDef {
-			actionDefault = def
-		}
+	actionInputName, actionDefault, hasDefault := actionInputName(flag)
+	if actionInputName == "" {
+		return "", nil, "", nil // Explicitly skipped
 	}
 
 	actionHelp := strings.TrimSpace(flag.Help)
@@ -221,6 +212,11 @@ func (b *actionBuilder) processFlag(flag *kong.Flag) (string, *actionInput, stri
 		return "", nil, "", fmt.Errorf("flag %q has no help text", flag.Name)
 	}
 
+	// Flag defaults are left off the action input so that an empty input doesn't override the config file.
+	if !hasDefault && flag.Default != "" {
+		actionHelp += "\n\n" + fmt.Sprintf(actionDefaultSuffix, flag.Default)
+	}
+
 	var selectedTmpl *template.Template
 	switch {
 	case flag.IsBool():
@@ -255,6 +251,23 @@ func (b *actionBuilder) processFlag(flag *kong.Flag) (string, *actionInput, stri
 	return actionInputName, input, tplBuffer.String(), nil
 }
 
+// actionInputName returns the name of the action input for flag along with the default value explicitly set in the
+// action tag. The returned name is empty when flag has no corresponding input.
+func actionInputName(flag *kong.Flag) (name, def string, hasDef bool) {
+	name = flag.Name
+	if !flag.Tag.Has(actionTagName) {
+		return name, "", false
+	}
+	input, def, hasDef := strings.Cut(flag.Tag.Get(actionTagName), ",")
+	if input == "-" {
+		return "", "", false
+	}
+	if input != "" {
+		name = input
+	}
+	return name, def, hasDef
+}
+
 // buildOutputs creates the map of action outputs.
 func (b *actionBuilder) buildOutputs() *orderedmap.OrderedMap[string, compositeOutput] {
 	outputs := orderedmap.New[string, compositeOutput]()
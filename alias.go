@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// latestAliasTag returns the --alias-latest tag. It is "latest" with the tag prefix's directory, so components get
+// their own.
+func (o *Runner) latestAliasTag() string {
+	return strings.TrimSuffix(o.TagPrefix, "v") + "latest"
+}
+
+// aliasTags returns the floating tags --alias-tags moves to the release: the major version, the major.minor version
+// and, with --alias-latest, the latest tag. Prereleases have none. An alias is left out when a higher stable version
+// it covers is already tagged, so releases from maintenance branches don't move aliases backward.
+func (o *Runner) aliasTags(ctx context.Context, result *Result) ([]string, error) {
+	version := result.ReleaseVersion
+	if !o.AliasTags || version == nil || version.Prerelease() != "" {
+		return nil, nil
+	}
+	out, err := o.runCmd(ctx, nil, "git", "tag", "--list", o.TagPrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	newest, newestMajor, newestMinor := true, true, true
+	for tag := range strings.Lines(out) {
+		ver, e := semver.StrictNewVersion(strings.TrimPrefix(strings.TrimSpace(tag), o.TagPrefix))
+		if e != nil || ver.Prerelease() != "" || !ver.GreaterThan(version) {
+			continue
+		}
+		newest = false
+		if ver.Major() == version.Major() {
+			newestMajor = false
+			if ver.Minor() == version.Minor() {
+				newestMinor = false
+			}
+		}
+	}
+	var aliases []string
+	if newestMajor {
+		aliases = append(aliases, fmt.Sprintf("%s%d", o.TagPrefix, version.Major()))
+	}
+	if newestMinor {
+		aliases = append(aliases, fmt.Sprintf("%s%d.%d", o.TagPrefix, version.Major(), version.Minor()))
+	}
+	if o.AliasLatest && newest {
+		aliases = append(aliases, o.latestAliasTag())
+	}
+	return aliases, nil
+}
+
+// moveAliasTags force-updates the alias tags to the release tag's commit, locally and on the push remote, and records
+// them in result.AliasTags.
+func (o *Runner) moveAliasTags(ctx context.Context, result *Result) error {
+	aliases, err := o.aliasTags(ctx, result)
+	if err != nil || len(aliases) == 0 {
+		return err
+	}
+	target := "refs/tags/" + result.ReleaseTag + "^{commit}"
+	refs := make([]string, len(aliases))
+	for i, alias := range aliases {
+		_, err = o.runCmd(ctx, nil, "git", "tag", "--force", alias, target)
+		if err != nil {
+			return err
+		}
+		refs[i] = "refs/tags/" + alias
+	}
+	if o.APIPush {
+		err = o.apiMoveTags(ctx, result.ReleaseTag, aliases)
+	} else {
+		_, err = o.runCmd(ctx, nil, "git", append([]string{"push", "--force", o.PushRemote}, refs...)...)
+	}
+	if err != nil {
+		return err
+	}
+	result.AliasTags = aliases
+	return nil
+}
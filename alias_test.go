@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_aliasTags(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, script string) (origin, clone string) {
+		t.Helper()
+		return setupTestRepo(t, "v3.1.0", script)
+	}
+
+	newRunner := func(t *testing.T, clone string) *Runner {
+		t.Helper()
+		runner := newTestRunner(t, clone)
+		runner.AliasTags = true
+		runner.AliasLatest = true
+		return runner
+	}
+
+	requireTagsAt := func(t *testing.T, dir, commit string, tags ...string) {
+		t.Helper()
+		for _, tag := range tags {
+			require.Equal(t, commit, mustRunCmd(t, dir, "git", "rev-parse", tag+"^{commit}"), tag)
+		}
+	}
+
+	t.Run("moves aliases on stable releases", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t, "")
+		plan, err := newRunner(t, clone).plan(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, []string{"v3", "v3.2", "latest"}, plan.AliasTags)
+
+		got, err := newRunner(t, clone).run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "v3.2.0", got.ReleaseTag)
+		require.Equal(t, []string{"v3", "v3.2", "latest"}, got.AliasTags)
+		head := mustRunCmd(t, clone, "git", "rev-parse", "HEAD")
+		requireTagsAt(t, origin, head, "v3", "v3.2", "latest")
+
+		// the next release force-updates the existing aliases
+		mustRunCmd(t, clone, "git", "commit", "-q", "--allow-empty", "-m", "fix: fix bar")
+		got, err = newRunner(t, clone).run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "v3.2.1", got.ReleaseTag)
+		require.Equal(t, []string{"v3", "v3.2", "latest"}, got.AliasTags)
+		head = mustRunCmd(t, clone, "git", "rev-parse", "HEAD")
+		requireTagsAt(t, origin, head, "v3", "v3.2", "latest")
+		requireTagsAt(t, clone, head, "v3", "v3.2", "latest")
+	})
+
+	t.Run("prereleases don't move aliases", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t, "")
+		runner := newRunner(t, clone)
+		runner.ForcePrerelease = true
+		got, err := runner.run(t.Context())
+		require.NoError(t, err)
+		require.True(t, got.CreatedTag)
+		require.Empty(t, got.AliasTags)
+		require.Empty(t, mustRunCmd(t, origin, "git", "tag", "--list", "v3", "v3.2", "latest"))
+	})
+
+	t.Run("older releases don't move newer aliases", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t, `
+git checkout -q -b next
+git commit -q --allow-empty -m "next"
+git tag v4.0.0
+git checkout -q main
+`)
+		got, err := newRunner(t, clone).run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "v3.2.0", got.ReleaseTag)
+		require.Equal(t, []string{"v3", "v3.2"}, got.AliasTags)
+		require.Empty(t, mustRunCmd(t, origin, "git", "tag", "--list", "latest"))
+	})
+
+	t.Run("failing to move aliases keeps the tag", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t, `
+cat > .git/hooks/pre-receive <<'EOF'
+#!/bin/sh
+! grep -q ' refs/tags/latest$'
+EOF
+chmod +x .git/hooks/pre-receive
+`)
+		got, err := newRunner(t, clone).run(t.Context())
+		require.ErrorContains(t, err, "moving alias tags after v3.2.0 was tagged")
+		require.NotNil(t, got)
+		require.True(t, got.CreatedTag)
+		require.Equal(t, "v3.2.0", got.ReleaseTag)
+		require.Equal(t, "v3.2.0", mustRunCmd(t, origin, "git", "tag", "--list", "v3.2.0"))
+	})
+}
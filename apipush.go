@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/willabides/release-train/v3/internal/github"
+)
+
+// gitDataClient returns the GithubClient as a GitDataClient for --api-push.
+func (o *Runner) gitDataClient() (GitDataClient, error) {
+	client, ok := o.GithubClient.(GitDataClient)
+	if !ok {
+		return nil, errors.New("--api-push is only supported with GitHub")
+	}
+	return client, nil
+}
+
+// apiPushTag creates the local tag on GitHub with the Git Data API instead of pushing it. Commits the tag needs that
+// aren't on the push remote are created first. Annotated tags keep their message, but not their tagger or signature.
+func (o *Runner) apiPushTag(ctx context.Context, tag string) error {
+	client, err := o.gitDataClient()
+	if err != nil {
+		return err
+	}
+	ref := "refs/tags/" + tag
+	kind, err := o.runCmd(ctx, nil, "git", "cat-file", "-t", ref)
+	if err != nil {
+		return err
+	}
+	var message string
+	if kind == "tag" {
+		message, err = o.localTagMessage(ctx, ref)
+		if err != nil {
+			return err
+		}
+	}
+	sha, err := o.apiCommit(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	return client.CreateTag(ctx, o.repoOwner(), o.repoName(), &github.NewTag{Name: tag, Sha: sha, Message: message})
+}
+
+// localTagMessage returns the message of the annotated tag ref without its signature.
+func (o *Runner) localTagMessage(ctx context.Context, ref string) (string, error) {
+	contents, err := o.runCmd(ctx, nil, "git", "for-each-ref", "--format=%(contents)", ref)
+	if err != nil {
+		return "", err
+	}
+	signature, err := o.runCmd(ctx, nil, "git", "for-each-ref", "--format=%(contents:signature)", ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimSuffix(contents, signature)), nil
+}
+
+// apiPushTarget moves the release target's branch on GitHub to the target with the Git Data API. Like git push, it
+// fails unless the update is a fast-forward.
+func (o *Runner) apiPushTarget(ctx context.Context) error {
+	client, err := o.gitDataClient()
+	if err != nil {
+		return err
+	}
+	target, branch, err := o.targetBranch(ctx)
+	if err != nil || branch == "" {
+		return err
+	}
+	sha, err := o.apiCommit(ctx, client, target)
+	if err != nil {
+		return err
+	}
+	return client.FastForwardBranch(ctx, o.repoOwner(), o.repoName(), strings.TrimPrefix(branch, "refs/heads/"), sha)
+}
+
+// apiDeleteTag deletes a release tag created by apiPushTag.
+func (o *Runner) apiDeleteTag(ctx context.Context, tag string) error {
+	client, err := o.gitDataClient()
+	if err != nil {
+		return err
+	}
+	return client.DeleteTag(ctx, o.repoOwner(), o.repoName(), tag)
+}
+
+// apiCommit returns the sha on GitHub of the commit commitish resolves to. Local commits that aren't on the push remote
+// yet, such as the release commit or commits made by the pre-tag hook, are recreated oldest first with the Git Data
+// API. The recreated commits keep their message and author but get new shas because GitHub is their committer.
+func (o *Runner) apiCommit(ctx context.Context, client GitDataClient, commitish string) (string, error) {
+	sha, err := o.runCmd(ctx, nil, "git", "rev-parse", "--verify", commitish+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	out, err := o.runCmd(ctx, nil, "git", "rev-list", "--reverse", "--topo-order", "--parents",
+		sha, "--not", "--remotes="+o.PushRemote)
+	if err != nil {
+		return "", err
+	}
+	if o.apiCommits == nil {
+		o.apiCommits = map[string]string{}
+	}
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if _, ok := o.apiCommits[fields[0]]; ok {
+			continue
+		}
+		if len(fields) != 2 {
+			return "", fmt.Errorf("cannot create commit %s with the API because only commits with one parent are supported", fields[0])
+		}
+		parent := fields[1]
+		if created, ok := o.apiCommits[parent]; ok {
+			parent = created
+		}
+		var created string
+		created, err = o.apiCreateCommit(ctx, client, fields[0], fields[1], parent)
+		if err != nil {
+			return "", err
+		}
+		o.apiCommits[fields[0]] = created
+	}
+	if created, ok := o.apiCommits[sha]; ok {
+		return created, nil
+	}
+	return sha, nil
+}
+
+// apiCreateCommit recreates the local commit sha on top of apiParent with the changes sha makes to localParent.
+func (o *Runner) apiCreateCommit(ctx context.Context, client GitDataClient, sha, localParent, apiParent string) (string, error) {
+	info, err := o.runCmd(ctx, nil, "git", "show", "--no-patch", "--format=%an%x00%ae%x00%aI%x00%B", sha)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.SplitN(info, "\x00", 4)
+	if len(fields) != 4 {
+		return "", fmt.Errorf("unexpected commit info for %s: %q", sha, info)
+	}
+	date, err := time.Parse(time.RFC3339, fields[2])
+	if err != nil {
+		return "", err
+	}
+	files, err := o.apiFileChanges(ctx, localParent, sha)
+	if err != nil {
+		return "", err
+	}
+	return client.CreateCommit(ctx, o.repoOwner(), o.repoName(), &github.NewCommit{
+		Parent:  apiParent,
+		Message: fields[3],
+		Files:   files,
+		Author:  &github.CommitAuthor{Name: fields[0], Email: fields[1], Date: date},
+	})
+}
+
+// apiFileChanges returns the files that differ between the commits from and to.
+func (o *Runner) apiFileChanges(ctx context.Context, from, to string) ([]github.FileChange, error) {
+	out, err := o.runCmd(ctx, nil, "git", "diff-tree", "-r", "-z", "--no-renames", from, to)
+	if err != nil {
+		return nil, err
+	}
+	// each change is ":<old mode> <new mode> <old sha> <new sha> <status>" followed by the path
+	parts := strings.Split(out, "\x00")
+	var files []github.FileChange
+	for i := 0; i+1 < len(parts); i += 2 {
+		fields := strings.Fields(parts[i])
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected diff-tree output: %q", parts[i])
+		}
+		mode, blob, status, path := fields[1], fields[3], fields[4], parts[i+1]
+		if status == "D" {
+			files = append(files, github.FileChange{Path: path, Delete: true})
+			continue
+		}
+		if mode == "160000" {
+			return nil, fmt.Errorf("cannot create a commit that changes submodule %s with the API", path)
+		}
+		var content bytes.Buffer
+		_, err = o.runCmd(ctx, &runCmdOpts{stdout: &content, noLog: true}, "git", "cat-file", "blob", blob)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, github.FileChange{Path: path, Content: content.Bytes(), Mode: mode})
+	}
+	return files, nil
+}
+
+// apiMoveTags points tags at the commit of the release tag created by apiPushTag.
+func (o *Runner) apiMoveTags(ctx context.Context, releaseTag string, tags []string) error {
+	client, err := o.gitDataClient()
+	if err != nil {
+		return err
+	}
+	sha, err := o.apiCommit(ctx, client, "refs/tags/"+releaseTag)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		err = client.MoveTag(ctx, o.repoOwner(), o.repoName(), tag, sha)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
+)
+
+func TestRunner_apiPush(t *testing.T) {
+	t.Parallel()
+
+	newRunner := func(t *testing.T, f *githubFixture) *Runner {
+		t.Helper()
+		tagMessage, err := parseTagMessage("Release {{.Tag}}")
+		require.NoError(t, err)
+		return &Runner{
+			CheckoutDir:  f.clone,
+			Ref:          "main",
+			TagPrefix:    "v",
+			Repo:         "orgName/repoName",
+			PushRemote:   "origin",
+			TempDir:      t.TempDir(),
+			GithubClient: f.client,
+			CreateTag:    true,
+			Changelog:    "CHANGELOG.md",
+			TagMessage:   tagMessage,
+			APIPush:      true,
+			PreTagHook: `
+git rm -q old.txt
+printf new > new.txt
+git add new.txt
+git commit -q -m "bake"
+`,
+			now: func() time.Time { return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC) },
+		}
+	}
+
+	setup := func(t *testing.T) *githubFixture {
+		t.Helper()
+		f := setupGithubRepo(t, `
+printf '# Changelog\n\n## [Unreleased]\n' > CHANGELOG.md
+printf old > old.txt
+`)
+		// the pre-tag hook commits
+		mustRunCmd(t, f.clone, "git", "config", "user.name", "tester")
+		mustRunCmd(t, f.clone, "git", "config", "user.email", "tester@example.com")
+		return f
+	}
+
+	t.Run("creates commits and the tag with the API", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		runner := newRunner(t, f)
+		runner.AliasTags = true
+		got, err := runner.run(t.Context())
+		require.NoError(t, err)
+		require.True(t, got.CreatedTag)
+		require.Equal(t, []string{"v1", "v1.1"}, got.AliasTags)
+
+		tag := f.server.Tag(f.server.Ref(f.repo, "refs/tags/v1.1.0"))
+		require.NotNil(t, tag)
+		require.Equal(t, "Release v1.1.0", tag.Message)
+		require.Equal(t, tag.Object, f.server.Ref(f.repo, "refs/heads/main"))
+		require.Equal(t, tag.Object, f.server.Ref(f.repo, "refs/tags/v1"))
+		require.Equal(t, tag.Object, f.server.Ref(f.repo, "refs/tags/v1.1"))
+
+		release := f.server.Commit(tag.Object)
+		require.NotNil(t, release)
+		require.Equal(t, "Release v1.1.0", release.Message)
+		require.Contains(t, release.Files["CHANGELOG.md"], "## [1.1.0] - 2024-01-02\n\n### Features\n\n- add foo")
+
+		bake := f.server.Commit(release.Parents[0])
+		require.NotNil(t, bake)
+		require.Equal(t, &githubtest.Commit{
+			Sha:     release.Parents[0],
+			Message: "bake",
+			Parents: []string{f.feature},
+			Files:   map[string]string{"new.txt": "new"},
+			Author:  "tester <tester@example.com>",
+		}, bake)
+
+		// the tag is created locally, but nothing is pushed to origin
+		require.Equal(t, "tag", mustRunCmd(t, f.clone, "git", "cat-file", "-t", "v1.1.0"))
+		require.Empty(t, mustRunCmd(t, f.clone, "git", "ls-remote", "--tags", "origin", "v1.1.0"))
+		require.Equal(t, f.feature, mustRunCmd(t, f.origin, "git", "rev-parse", "main"))
+	})
+
+	t.Run("failure deletes the tag", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		runner := newRunner(t, f)
+		runner.PostTagHook = "exit 1"
+		_, err := runner.run(t.Context())
+		require.ErrorContains(t, err, "post-tag hook failed")
+		require.Empty(t, f.server.Ref(f.repo, "refs/tags/v1.1.0"))
+		require.Equal(t, f.feature, f.server.Ref(f.repo, "refs/heads/main"))
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		plan, err := newRunner(t, f).plan(t.Context())
+		require.NoError(t, err)
+		require.True(t, plan.Tag.API)
+		require.True(t, plan.Push.API)
+		require.Empty(t, f.server.Ref(f.repo, "refs/tags/v1.1.0"))
+	})
+}
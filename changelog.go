@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const changelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+`
+
+// changelogChange returns the changelog file with a section for this release added.
+func (o *Runner) changelogChange(ctx context.Context, result *Result) (*releaseFileChange, error) {
+	path, ok := checkoutPath(o.Changelog)
+	if !ok {
+		return nil, fmt.Errorf("changelog %q must be inside the checkout directory", o.Changelog)
+	}
+	target, err := o.getReleaseTarget()
+	if err != nil {
+		return nil, err
+	}
+	content, err := o.readTargetFile(ctx, target, path)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := o.builtinReleaseNotes(result)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now
+	if o.now != nil {
+		now = o.now
+	}
+	section := changelogSection(result.ReleaseVersion.String(), now().UTC().Format(time.DateOnly), notes)
+	return &releaseFileChange{
+		Path:    path,
+		Content: []byte(insertChangelogSection(content, section)),
+	}, nil
+}
+
+// readTargetFile returns the content of path at commitish or an empty string when the file doesn't exist there.
+func (o *Runner) readTargetFile(ctx context.Context, commitish, path string) (string, error) {
+	object := commitish + ":./" + path
+	_, err := o.runCmd(ctx, &runCmdOpts{noLog: true}, "git", "cat-file", "-e", object)
+	if err != nil {
+		if asExitErr(err) != nil {
+			return "", nil
+		}
+		return "", err
+	}
+	var buf bytes.Buffer
+	_, err = o.runCmd(ctx, &runCmdOpts{stdout: &buf}, "git", "cat-file", "blob", object)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// changelogSection returns a Keep a Changelog style section for a release.
+func changelogSection(version, date, notes string) string {
+	section := fmt.Sprintf("## [%s] - %s\n", version, date)
+	if notes != "" {
+		section += "\n" + notes + "\n"
+	}
+	return section
+}
+
+// insertChangelogSection adds section to a changelog above the most recent release. An "Unreleased" section stays at
+// the top.
+func insertChangelogSection(changelog, section string) string {
+	if strings.TrimSpace(changelog) == "" {
+		return changelogHeader + "\n" + section
+	}
+	heading := regexp.MustCompile(`(?m)^## `)
+	unreleased := regexp.MustCompile(`(?i)^## \[?unreleased\]?\s*$`)
+	for _, loc := range heading.FindAllStringIndex(changelog, -1) {
+		line, _, _ := strings.Cut(changelog[loc[0]:], "\n")
+		if unreleased.MatchString(line) {
+			continue
+		}
+		return changelog[:loc[0]] + section + "\n" + changelog[loc[0]:]
+	}
+	return strings.TrimRight(changelog, "\n") + "\n\n" + section
+}
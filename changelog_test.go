@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_insertChangelogSection(t *testing.T) {
+	section := "## [1.1.0] - 2024-05-06\n\n- new\n"
+	for _, td := range []struct {
+		name      string
+		changelog string
+		want      string
+	}{
+		{
+			name:      "empty",
+			changelog: "",
+			want:      changelogHeader + "\n## [1.1.0] - 2024-05-06\n\n- new\n",
+		},
+		{
+			name:      "above previous release",
+			changelog: "# Changelog\n\n## [1.0.0] - 2024-01-01\n\n- old\n",
+			want:      "# Changelog\n\n## [1.1.0] - 2024-05-06\n\n- new\n\n## [1.0.0] - 2024-01-01\n\n- old\n",
+		},
+		{
+			name:      "below unreleased",
+			changelog: "# Changelog\n\n## [Unreleased]\n\n## 1.0.0\n",
+			want:      "# Changelog\n\n## [Unreleased]\n\n## [1.1.0] - 2024-05-06\n\n- new\n\n## 1.0.0\n",
+		},
+		{
+			name:      "no releases",
+			changelog: "# Changelog\n\n## Unreleased\n\n",
+			want:      "# Changelog\n\n## Unreleased\n\n## [1.1.0] - 2024-05-06\n\n- new\n",
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			require.Equal(t, td.want, insertChangelogSection(td.changelog, section))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alecthomas/kong"
+)
+
+// defaultCmd runs when no command is given. It is the release-train invocation the GitHub Action uses, so its
+// behavior depends on flags like --create-tag, --create-release and --check-pr.
+type defaultCmd struct{}
+
+func (*defaultCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	if root.GenerateAction {
+		return root.generateAction(kongCtx)
+	}
+	return root.runRelease(ctx, kongCtx.Stdout, kongCtx.Stderr)
+}
+
+type nextCmd struct{}
+
+func (*nextCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	return root.withRunner(ctx, kongCtx.Stdout, kongCtx.Stderr, func(runner *Runner) error {
+		err := runner.rejectShallowCheckout(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := runner.next(ctx)
+		if err != nil {
+			return err
+		}
+		return root.writeResult(result)
+	})
+}
+
+type releaseCmd struct {
+	TagOnly bool `help:"${tag_only_help}"`
+}
+
+func (r *releaseCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	if root.CheckPR != 0 {
+		return errors.New("release can't be combined with --check-pr")
+	}
+	if root.GitOnly && !r.TagOnly {
+		return errors.New("--git-only can't create releases. Use release --tag-only")
+	}
+	root.CreateTag = true
+	root.CreateRelease = !r.TagOnly
+	return root.runRelease(ctx, kongCtx.Stdout, kongCtx.Stderr)
+}
+
+type checkPRCmd struct {
+	Number int `arg:"" help:"${pr_number_help}"`
+}
+
+func (p *checkPRCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	root.CheckPR = p.Number
+	return root.runRelease(ctx, kongCtx.Stdout, kongCtx.Stderr)
+}
+
+type prevTagCmd struct {
+	StableOnly bool `help:"${stable_only_help}"`
+}
+
+func (p *prevTagCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	if len(root.Component) > 0 {
+		return errors.New("prev-tag doesn't support --component. Use --tag-prefix instead")
+	}
+	tag, err := getPrevTag(ctx, &getPrevTagOpts{
+		Head:       root.Ref,
+		RepoDir:    root.CheckoutDir,
+		TagPrefix:  root.TagPrefix,
+		StableOnly: p.StableOnly,
+	})
+	if err != nil {
+		return err
+	}
+	if tag == "" {
+		return nil
+	}
+	_, err = fmt.Fprintln(kongCtx.Stdout, tag)
+	return err
+}
+
+type notesCmd struct{}
+
+func (*notesCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	if len(root.Component) > 0 {
+		return errors.New("notes doesn't support --component")
+	}
+	return root.withRunner(ctx, kongCtx.Stdout, kongCtx.Stderr, func(runner *Runner) error {
+		err := runner.rejectShallowCheckout(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := runner.next(ctx)
+		if err != nil {
+			return err
+		}
+		if result.ReleaseVersion == nil {
+			return errors.New("there is no release version to write notes for")
+		}
+		notes, err := runner.getReleaseNotes(ctx, result)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(kongCtx.Stdout, notes)
+		return err
+	})
+}
+
+type planCmd struct {
+	Format string `default:"text" help:"${plan_format_help}" enum:"text,json"`
+}
+
+func (p *planCmd) Run(ctx context.Context, root *rootCmd, kongCtx *kong.Context) error {
+	root.setupLogging()
+	if len(root.Component) > 0 {
+		return errors.New("plan doesn't support --component")
+	}
+	return root.withRunner(ctx, kongCtx.Stderr, kongCtx.Stderr, func(runner *Runner) error {
+		plan, err := runner.plan(ctx)
+		if err != nil {
+			return err
+		}
+		if p.Format == "json" {
+			enc := json.NewEncoder(kongCtx.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(plan)
+		}
+		return plan.writeText(kongCtx.Stdout)
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/stretchr/testify/require"
+)
+
+// runCommand parses args and runs the selected command with output captured.
+func runCommand(t *testing.T, args ...string) (stdout string, _ error) {
+	t.Helper()
+	var root rootCmd
+	var out, errOut bytes.Buffer
+	k, err := kong.New(
+		&root,
+		helpVars(),
+		kong.Exit(func(int) { t.Fatal("unexpected exit") }),
+		kong.Writers(&out, &errOut),
+		kong.BindTo(t.Context(), (*context.Context)(nil)),
+	)
+	require.NoError(t, err)
+	kongCtx, err := k.Parse(args)
+	require.NoError(t, err)
+	err = kongCtx.Run()
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("parse", func(t *testing.T) {
+		t.Parallel()
+		for _, td := range []struct {
+			args []string
+			want string
+		}{
+			{args: nil, want: "default"},
+			{args: []string{"--create-release", "--check-pr", "12"}, want: "default"},
+			{args: []string{"next"}, want: "next"},
+			{args: []string{"release", "--tag-only"}, want: "release"},
+			{args: []string{"check-pr", "12"}, want: "check-pr <number>"},
+			{args: []string{"prev-tag", "--stable-only"}, want: "prev-tag"},
+			{args: []string{"notes", "--release-notes", "builtin"}, want: "notes"},
+			{args: []string{"plan", "--format", "json"}, want: "plan"},
+		} {
+			var root rootCmd
+			k, err := kong.New(&root, helpVars(), kong.Exit(func(int) { t.Fatal("unexpected exit") }))
+			require.NoError(t, err)
+			kongCtx, err := k.Parse(append([]string{"-C", t.TempDir()}, td.args...))
+			require.NoError(t, err)
+			require.Equal(t, td.want, kongCtx.Command(), "args: %q", td.args)
+		}
+	})
+
+	t.Run("prev-tag", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		mustRunCmd(t, dir, "sh", "-c", `
+git init -q
+git config user.name 'tester'
+git config user.email 'tester'
+git commit -q --allow-empty -m "first"
+git tag v1.0.0
+git commit -q --allow-empty -m "second"
+git tag v1.1.0-rc.1
+git tag x2.0.0
+`)
+		got, err := runCommand(t, "-C", dir, "prev-tag")
+		require.NoError(t, err)
+		require.Equal(t, "v1.1.0-rc.1\n", got)
+
+		got, err = runCommand(t, "-C", dir, "prev-tag", "--stable-only")
+		require.NoError(t, err)
+		require.Equal(t, "v1.0.0\n", got)
+
+		got, err = runCommand(t, "-C", dir, "prev-tag", "--tag-prefix", "y")
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("release rejects --git-only", func(t *testing.T) {
+		t.Parallel()
+		_, err := runCommand(t, "-C", t.TempDir(), "--git-only", "release")
+		require.EqualError(t, err, "--git-only can't create releases. Use release --tag-only")
+	})
+	t.Run("github app flags", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		_, err := runCommand(t, "-C", dir, "--repo", "o/r", "--forge", "github", "--github-app-key", "key", "next")
+		require.EqualError(t, err, "--github-app-key and --github-app-installation-id need --github-app-id")
+		_, err = runCommand(t, "-C", dir, "--repo", "o/r", "--forge", "github", "--github-app-id", "12", "next")
+		require.EqualError(t, err, "--github-app-id needs --github-app-key")
+		_, err = runCommand(t, "-C", dir, "--repo", "o/r", "--forge", "gitlab", "--github-app-id", "12", "--github-app-key", "key", "next")
+		require.EqualError(t, err, "--github-app-id is only supported with GitHub, not gitlab")
+		_, err = runCommand(t, "-C", dir, "--repo", "o/r", "--forge", "github", "--github-app-id", "12", "--github-app-key", "key", "next")
+		require.EqualError(t, err, "the GitHub App private key isn't PEM encoded")
+	})
+	t.Run("api push needs GitHub", func(t *testing.T) {
+		t.Parallel()
+		_, err := runCommand(t, "-C", t.TempDir(), "--repo", "o/r", "--forge", "gitlab", "--api-push", "next")
+		require.EqualError(t, err, "--api-push is only supported with GitHub, not gitlab")
+	})
+}
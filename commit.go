@@ -36,21 +36,34 @@ func (c gitCommit) validate() error {
 		}
 	}
 
-	missingLabelPR := len(c.Pulls) > 0
+	if len(c.Pulls) > 0 && !c.hasLevelLabels() {
+		return fmt.Errorf("commit %s has no labels on associated pull requests: %v", c.Sha, c.Pulls)
+	}
+	return nil
+}
+
+// hasLevelLabels returns true if any of the commit's pulls has a level label or the stable label.
+func (c gitCommit) hasLevelLabels() bool {
 	for _, pull := range c.Pulls {
 		if len(pull.LevelLabels) > 0 || pull.HasStableLabel {
-			missingLabelPR = false
-			break
+			return true
 		}
 	}
-	if missingLabelPR {
-		return fmt.Errorf("commit %s has no labels on associated pull requests: %v", c.Sha, c.Pulls)
-	}
-	return nil
+	return false
 }
 
 type gitCommits []gitCommit
 
+func (c gitCommits) validate() error {
+	for _, commit := range c {
+		err := commit.validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c gitCommits) pulls() ghPulls {
 	var pulls []ghPull
 	for _, commit := range c {
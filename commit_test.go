@@ -99,3 +99,16 @@ func TestCommit_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestGitCommits_pulls(t *testing.T) {
+	t.Parallel()
+	// a pull's commits can get different levels from their Conventional Commit messages
+	minor := ghPull{Number: 1, ChangeLevel: changeLevelMinor}
+	patch := ghPull{Number: 1, ChangeLevel: changeLevelPatch}
+	for _, commits := range []gitCommits{
+		{{Sha: "a", Pulls: ghPulls{minor}}, {Sha: "b", Pulls: ghPulls{patch}}},
+		{{Sha: "a", Pulls: ghPulls{patch}}, {Sha: "b", Pulls: ghPulls{minor}}},
+	} {
+		require.Equal(t, ghPulls{minor}, commits.pulls())
+	}
+}
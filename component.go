@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// component is a part of a monorepo that is released on its own with its own tag prefix. Only commits that change
+// files in its paths count toward its releases.
+type component struct {
+	Name      string
+	TagPrefix string
+	// Paths are relative to the checkout directory.
+	Paths []string
+}
+
+// ComponentResult is the Result for one component.
+type ComponentResult struct {
+	Name string `json:"name"`
+	*Result
+}
+
+// parseComponent parses a --component value. The format is NAME:TAG_PREFIX:PATH[,PATH...]. The tag prefix defaults
+// to NAME/v.
+func parseComponent(spec string) (*component, error) {
+	name, rest, _ := strings.Cut(spec, ":")
+	tagPrefix, paths, ok := strings.Cut(rest, ":")
+	if name == "" || !ok || paths == "" {
+		return nil, errors.New("the value must be NAME:TAG_PREFIX:PATH[,PATH...]")
+	}
+	c := component{
+		Name:      name,
+		TagPrefix: tagPrefix,
+	}
+	if c.TagPrefix == "" {
+		c.TagPrefix = name + "/v"
+	}
+	for _, p := range strings.Split(paths, ",") {
+		cleaned, inside := checkoutPath(strings.TrimSpace(p))
+		if !inside {
+			return nil, fmt.Errorf("path %q must be inside the checkout directory", p)
+		}
+		c.Paths = append(c.Paths, cleaned)
+	}
+	return &c, nil
+}
+
+// validateComponents checks that component names and tag prefixes are unique.
+func validateComponents(components []*component) error {
+	names := map[string]bool{}
+	prefixes := map[string]string{}
+	for _, c := range components {
+		if names[c.Name] {
+			return fmt.Errorf("component %q is defined more than once", c.Name)
+		}
+		names[c.Name] = true
+		other, ok := prefixes[c.TagPrefix]
+		if ok {
+			return fmt.Errorf("components %q and %q both use tag prefix %q", other, c.Name, c.TagPrefix)
+		}
+		prefixes[c.TagPrefix] = c.Name
+	}
+	return nil
+}
+
+// touchesPaths returns true if file is one of paths or is in a directory in paths.
+func touchesPaths(file string, paths []string) bool {
+	for _, p := range paths {
+		if p == "." || file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// componentRunner returns a Runner for one component. Its --changelog and --version-file paths are relative to the
+// component's first path.
+func (o *Runner) componentRunner(idx int) (*Runner, error) {
+	c := o.Components[idx]
+	runner := *o
+	runner.Components = nil
+	runner.Component = c.Name
+	runner.Paths = c.Paths
+	runner.TagPrefix = c.TagPrefix
+	if o.InitialTag != "" {
+		runner.InitialTag = c.TagPrefix + strings.TrimPrefix(o.InitialTag, o.TagPrefix)
+	}
+	runner.TempDir = filepath.Join(o.TempDir, "component-"+strconv.Itoa(idx))
+	runner.ran = false
+	runner.errCleanups = nil
+	runner.pulls = nil
+	if o.Changelog != "" {
+		runner.Changelog = path.Join(c.Paths[0], o.Changelog)
+	}
+	runner.VersionFiles = make([]*versionFile, len(o.VersionFiles))
+	for i, file := range o.VersionFiles {
+		f := *file
+		f.Path = path.Join(c.Paths[0], file.Path)
+		runner.VersionFiles[i] = &f
+	}
+	err := os.MkdirAll(runner.TempDir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	return &runner, nil
+}
+
+// eachComponent calls fn with a Runner for each component in order and combines their results. The combined result
+// has the highest change level and every component's pulls, and reports a created tag or release when any component
+// created one. It stops at the first error, so components before it may have been released. Then the combined result
+// of those components is returned with the error.
+func (o *Runner) eachComponent(ctx context.Context, fn func(*Runner, context.Context) (*Result, error)) (*Result, error) {
+	result := Result{}
+	for i, c := range o.Components {
+		slog.Debug("starting component", slog.String("component", c.Name))
+		runner, err := o.componentRunner(i)
+		if err != nil {
+			return nil, err
+		}
+		componentResult, err := fn(runner, ctx)
+		if componentResult != nil {
+			result.addComponent(c.Name, componentResult)
+		}
+		if err != nil {
+			err = fmt.Errorf("component %s: %w", c.Name, err)
+			if len(result.Components) == 0 {
+				return nil, err
+			}
+			result.Pulls = result.Pulls.compact()
+			return &result, err
+		}
+	}
+	result.Pulls = result.Pulls.compact()
+	return &result, nil
+}
+
+// addComponent combines a component's result into r.
+func (r *Result) addComponent(name string, componentResult *Result) {
+	r.ChangeLevel = max(r.ChangeLevel, componentResult.ChangeLevel)
+	r.CreatedTag = r.CreatedTag || componentResult.CreatedTag
+	r.CreatedRelease = r.CreatedRelease || componentResult.CreatedRelease
+	r.Pulls = append(r.Pulls, componentResult.Pulls...)
+	r.AliasTags = append(r.AliasTags, componentResult.AliasTags...)
+	r.Components = append(r.Components, ComponentResult{Name: name, Result: componentResult})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseComponent(t *testing.T) {
+	t.Parallel()
+	for _, td := range []struct {
+		spec    string
+		want    component
+		wantErr string
+	}{
+		{spec: "tools::tools", want: component{Name: "tools", TagPrefix: "tools/v", Paths: []string{"tools"}}},
+		{spec: "api:api-v:api/, proto", want: component{Name: "api", TagPrefix: "api-v", Paths: []string{"api", "proto"}}},
+		{spec: "root:v:.", want: component{Name: "root", TagPrefix: "v", Paths: []string{"."}}},
+		{spec: "tools", wantErr: "the value must be NAME:TAG_PREFIX:PATH[,PATH...]"},
+		{spec: "tools:tools/v:", wantErr: "the value must be NAME:TAG_PREFIX:PATH[,PATH...]"},
+		{spec: ":v:tools", wantErr: "the value must be NAME:TAG_PREFIX:PATH[,PATH...]"},
+		{spec: "tools::../tools", wantErr: `path "../tools" must be inside the checkout directory`},
+	} {
+		t.Run(td.spec, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseComponent(td.spec)
+			if td.wantErr != "" {
+				require.EqualError(t, err, td.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &td.want, got)
+		})
+	}
+}
+
+func Test_validateComponents(t *testing.T) {
+	t.Parallel()
+	tools := &component{Name: "tools", TagPrefix: "tools/v"}
+	require.NoError(t, validateComponents([]*component{tools, {Name: "api", TagPrefix: "api/v"}}))
+	require.EqualError(t,
+		validateComponents([]*component{tools, {Name: "tools", TagPrefix: "other/v"}}),
+		`component "tools" is defined more than once`,
+	)
+	require.EqualError(t,
+		validateComponents([]*component{tools, {Name: "other", TagPrefix: "tools/v"}}),
+		`components "tools" and "other" both use tag prefix "tools/v"`,
+	)
+}
+
+func TestRunner_components(t *testing.T) {
+	t.Parallel()
+	origin := t.TempDir()
+	clone := t.TempDir()
+	mustRunCmd(t, origin, "sh", "-c", `
+git init -q -b main
+git config user.name 'tester'
+git config user.email 'tester'
+mkdir tools api web
+echo 1 > tools/a.txt
+echo 1 > api/a.txt
+echo 1 > web/a.txt
+git add .
+git commit -q -m "first"
+git tag tools/v1.0.0
+git tag api/v2.0.0
+git tag web/v0.1.0
+git tag v3.0.0
+echo 2 > tools/a.txt
+git commit -q -am "feat: add tools thing"
+echo 2 > README.md
+git add README.md
+git commit -q -m "feat!: not in a component"
+git checkout -q -b fix
+echo 2 > api/a.txt
+git commit -q -am "fix: fix api"
+git checkout -q main
+git merge -q --no-ff -m "chore: merge fix" fix
+`)
+	mustRunCmd(t, clone, "git", "clone", "-q", origin, ".")
+	var components []*component
+	for _, spec := range []string{"tools::tools", "api::api", "web::web", "docs:docs-v:docs"} {
+		c, err := parseComponent(spec)
+		require.NoError(t, err)
+		components = append(components, c)
+	}
+	runner := &Runner{
+		CheckoutDir: clone,
+		Ref:         "main",
+		TagPrefix:   "v",
+		InitialTag:  "v0.1.0",
+		Repo:        "orgName/repoName",
+		PushRemote:  "origin",
+		TempDir:     t.TempDir(),
+		GitOnly:     true,
+		LevelSource: levelSourceConventional,
+		CreateTag:   true,
+		Components:  components,
+	}
+	got, err := runner.run(t.Context())
+	require.NoError(t, err)
+	pull := func(subject, label string, level changeLevel) ghPull {
+		return ghPull{
+			Commit:      mustRunCmd(t, origin, "git", "rev-parse", ":/"+subject),
+			Title:       subject,
+			LevelLabels: []string{label},
+			ChangeLevel: level,
+		}
+	}
+	toolsPull := pull("feat: add tools thing", labelMinor, changeLevelMinor)
+	apiPulls := ghPulls{
+		pull("fix: fix api", labelPatch, changeLevelPatch),
+		pull("chore: merge fix", labelNone, changeLevelNone),
+	}.compact()
+	require.Equal(t, &Result{
+		ChangeLevel: changeLevelMinor,
+		Pulls:       append(ghPulls{toolsPull}, apiPulls...).compact(),
+		CreatedTag:  true,
+		Components: []ComponentResult{
+			{Name: "tools", Result: &Result{
+				PreviousRef:           "tools/v1.0.0",
+				PreviousVersion:       "1.0.0",
+				PreviousStableRef:     "tools/v1.0.0",
+				PreviousStableVersion: "1.0.0",
+				ReleaseVersion:        semver.MustParse("1.1.0"),
+				ReleaseTag:            "tools/v1.1.0",
+				ChangeLevel:           changeLevelMinor,
+				Pulls:                 ghPulls{toolsPull},
+				CreatedTag:            true,
+			}},
+			{Name: "api", Result: &Result{
+				PreviousRef:           "api/v2.0.0",
+				PreviousVersion:       "2.0.0",
+				PreviousStableRef:     "api/v2.0.0",
+				PreviousStableVersion: "2.0.0",
+				ReleaseVersion:        semver.MustParse("2.0.1"),
+				ReleaseTag:            "api/v2.0.1",
+				ChangeLevel:           changeLevelPatch,
+				Pulls:                 apiPulls,
+				CreatedTag:            true,
+			}},
+			{Name: "web", Result: &Result{
+				PreviousRef:           "web/v0.1.0",
+				PreviousVersion:       "0.1.0",
+				PreviousStableRef:     "web/v0.1.0",
+				PreviousStableVersion: "0.1.0",
+				ReleaseVersion:        semver.MustParse("0.1.0"),
+				ReleaseTag:            "web/v0.1.0",
+				ChangeLevel:           changeLevelNone,
+			}},
+			{Name: "docs", Result: &Result{
+				FirstRelease:   true,
+				ReleaseVersion: semver.MustParse("0.1.0"),
+				ReleaseTag:     "docs-v0.1.0",
+				CreatedTag:     true,
+			}},
+		},
+	}, got)
+	require.Equal(t,
+		"api/v2.0.0\napi/v2.0.1\ndocs-v0.1.0\ntools/v1.0.0\ntools/v1.1.0\nv3.0.0\nweb/v0.1.0",
+		mustRunCmd(t, origin, "git", "tag", "--list"),
+	)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+const configVersion = 1
+
+// defaultConfigFiles are the files release-train looks for in the checkout directory when --config isn't set.
+func defaultConfigFiles() []string {
+	return []string{".release-train.yaml", ".release-train.yml"}
+}
+
+// configExcludedFlags are flags that can't be set from a config file, mapped to the reason why.
+func configExcludedFlags() map[string]string {
+	return map[string]string{
+		"help":            "it is only meaningful on the command line",
+		"version":         "it is reserved for the config file version",
+		"generate-action": "it is only meaningful on the command line",
+		"config":          "it would refer to itself",
+		"checkout-dir":    "the config file is read from the checkout directory",
+		"github-token":    "secrets don't belong in the repository; use the GITHUB_TOKEN environment variable",
+		"gitlab-token":    "secrets don't belong in the repository; use the GITLAB_TOKEN environment variable",
+		"gitea-token":     "secrets don't belong in the repository; use the GITEA_TOKEN environment variable",
+		"github-app-key":  "secrets don't belong in the repository; use the GITHUB_APP_KEY environment variable",
+		"check-pr":        "it is specific to a single run",
+	}
+}
+
+// repoConfig is the content of a release-train config file. Each option key is either the name of a command line
+// flag or the name of the corresponding GitHub Action input.
+type repoConfig struct {
+	Version int            `yaml:"version"`
+	Options map[string]any `yaml:",inline"`
+
+	path  string
+	flags map[string]*kong.Flag
+}
+
+// findConfigFile returns the config file to use. An explicitly set file must exist. Otherwise, it returns the first
+// of defaultConfigFiles that exists in checkoutDir or "" if none do.
+func findConfigFile(explicit, checkoutDir string) (string, error) {
+	if explicit != "" {
+		_, err := os.Stat(explicit)
+		if err != nil {
+			return "", fmt.Errorf("config file: %w", err)
+		}
+		return explicit, nil
+	}
+	for _, name := range defaultConfigFiles() {
+		filename := filepath.Join(checkoutDir, name)
+		info, err := os.Stat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		if !info.IsDir() {
+			return filename, nil
+		}
+	}
+	return "", nil
+}
+
+// loadConfig reads and validates the config file at filename against the top-level flags in kongCtx.
+func loadConfig(kongCtx *kong.Context, filename string) (*repoConfig, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := repoConfig{
+		path:  filename,
+		flags: map[string]*kong.Flag{},
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if cfg.Version != configVersion {
+		return nil, fmt.Errorf("%s: unsupported config version %d. The only supported version is %d", filename, cfg.Version, configVersion)
+	}
+	// only the top-level flags are options. Command flags are specific to a single run.
+	for _, flag := range kongCtx.Model.Flags {
+		cfg.flags[flag.Name] = flag
+		inputName, _, _ := actionInputName(flag)
+		if inputName != "" {
+			cfg.flags[inputName] = flag
+		}
+	}
+	err = cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validate checks that every option refers to a flag that may be set from a config file and that its value can be
+// parsed for that flag.
+func (c *repoConfig) validate() error {
+	excluded := configExcludedFlags()
+	keys := make([]string, 0, len(c.Options))
+	for key := range c.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	seen := map[*kong.Flag]string{}
+	var errs []error
+	for _, key := range keys {
+		flag := c.flags[key]
+		if flag == nil {
+			errs = append(errs, fmt.Errorf("unknown option %q. Valid options are: %s", key, strings.Join(c.validKeys(), ", ")))
+			continue
+		}
+		reason, ok := excluded[flag.Name]
+		if ok {
+			errs = append(errs, fmt.Errorf("option %q can't be set in a config file because %s", key, reason))
+			continue
+		}
+		other, ok := seen[flag]
+		if ok {
+			errs = append(errs, fmt.Errorf("options %q and %q both set %s", other, key, flag.ShortSummary()))
+			continue
+		}
+		seen[flag] = key
+		err := parseFlagValue(flag, c.Options[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for option %q: %w", key, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", c.path, errors.Join(errs...))
+}
+
+// validKeys returns the sorted flag names that may be set from a config file.
+func (c *repoConfig) validKeys() []string {
+	excluded := configExcludedFlags()
+	var keys []string
+	for key, flag := range c.flags {
+		if key != flag.Name || flag.Hidden {
+			continue
+		}
+		if _, ok := excluded[flag.Name]; ok {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// option returns the configured value for flag.
+func (c *repoConfig) option(flag *kong.Flag) (any, bool) {
+	for key, val := range c.Options {
+		if c.flags[key] == flag {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve implements kong.Resolver. Values from the config file take precedence over flag defaults, but not over
+// flags set on the command line or from environment variables.
+func (c *repoConfig) Resolve(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (any, error) {
+	for _, env := range flag.Envs {
+		if _, ok := os.LookupEnv(env); ok {
+			return nil, nil //nolint:nilnil // nil means unresolved to kong
+		}
+	}
+	val, ok := c.option(flag)
+	if !ok {
+		return nil, nil //nolint:nilnil // nil means unresolved to kong
+	}
+	return val, nil
+}
+
+// Validate implements kong.Resolver. Validation happens in loadConfig.
+func (*repoConfig) Validate(*kong.Application) error {
+	return nil
+}
+
+// parseFlagValue checks that val can be decoded as a value for flag without modifying flag.
+func parseFlagValue(flag *kong.Flag, val any) error {
+	target := reflect.New(flag.Target.Type()).Elem()
+	err := flag.Parse(kong.Scan().PushTyped(val, kong.FlagValueToken), target)
+	if err != nil {
+		return err
+	}
+	if flag.Enum == "" {
+		return nil
+	}
+	return checkFlagEnum(flag, target)
+}
+
+func checkFlagEnum(flag *kong.Flag, target reflect.Value) error {
+	allowed := flag.EnumMap()
+	values := []string{fmt.Sprint(target.Interface())}
+	if target.Kind() == reflect.Slice {
+		values = values[:0]
+		for i := range target.Len() {
+			values = append(values, fmt.Sprint(target.Index(i).Interface()))
+		}
+	}
+	for _, v := range values {
+		if !allowed[v] {
+			return fmt.Errorf("must be one of %s but got %q", strings.Join(flag.EnumSlice(), ","), v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/stretchr/testify/require"
+)
+
+func parseRootCmd(t *testing.T, args ...string) (*rootCmd, error) {
+	t.Helper()
+	var root rootCmd
+	k, err := kong.New(&root, helpVars(), kong.Exit(func(int) { t.Fatal("unexpected exit") }))
+	require.NoError(t, err)
+	_, err = k.Parse(args)
+	return &root, err
+}
+
+func writeConfigFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	filename := filepath.Join(dir, ".release-train.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	return filename
+}
+
+func TestConfigFile(t *testing.T) {
+	t.Run("applies options", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, dir, `
+version: 1
+tag-prefix: x
+labels:
+  feat: semver:minor
+release-refs: [main, release/*]
+v0: true
+make-latest: "false"
+`)
+		root, err := parseRootCmd(t, "-C", dir)
+		require.NoError(t, err)
+		require.Equal(t, "x", root.TagPrefix)
+		require.Equal(t, map[string]string{"feat": labelMinor}, root.Label)
+		require.Equal(t, []string{"main", "release/*"}, root.ReleaseRef)
+		require.True(t, root.V0)
+		require.Equal(t, "false", root.MakeLatest)
+		require.Equal(t, "v0.0.0", root.InitialTag)
+	})
+
+	t.Run("flags take precedence", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, dir, `
+version: 1
+tag-prefix: x
+initial-tag: x1.0.0
+`)
+		root, err := parseRootCmd(t, "-C", dir, "--tag-prefix", "y")
+		require.NoError(t, err)
+		require.Equal(t, "y", root.TagPrefix)
+		require.Equal(t, "x1.0.0", root.InitialTag)
+	})
+
+	t.Run("explicit file", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "policy.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte("version: 1\npush-remote: upstream\n"), 0o600))
+		root, err := parseRootCmd(t, "-C", t.TempDir(), "--config", filename)
+		require.NoError(t, err)
+		require.Equal(t, "upstream", root.PushRemote)
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		_, err := parseRootCmd(t, "--config", filepath.Join(t.TempDir(), "nope.yaml"))
+		require.ErrorContains(t, err, "config file: ")
+	})
+
+	t.Run("no config file", func(t *testing.T) {
+		root, err := parseRootCmd(t, "-C", t.TempDir())
+		require.NoError(t, err)
+		require.Equal(t, "v", root.TagPrefix)
+	})
+
+	for _, td := range []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "missing version",
+			content: `tag-prefix: x`,
+			wantErr: "unsupported config version 0",
+		},
+		{
+			name:    "unknown option",
+			content: "version: 1\ntag-prefx: x",
+			wantErr: `unknown option "tag-prefx". Valid options are: `,
+		},
+		{
+			name:    "excluded option",
+			content: "version: 1\ngithub-token: abc",
+			wantErr: `option "github-token" can't be set in a config file because secrets don't belong in the repository`,
+		},
+		{
+			name:    "duplicate option",
+			content: "version: 1\nlabel: {a: semver:minor}\nlabels: {b: semver:patch}",
+			wantErr: `options "label" and "labels" both set --label`,
+		},
+		{
+			name:    "invalid enum",
+			content: "version: 1\nmake-latest: sometimes",
+			wantErr: `invalid value for option "make-latest": must be one of legacy,true,false,auto but got "sometimes"`,
+		},
+		{
+			name:    "invalid type",
+			content: "version: 1\nv0: [true]",
+			wantErr: `invalid value for option "v0": `,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigFile(t, dir, td.content)
+			_, err := parseRootCmd(t, "-C", dir)
+			require.ErrorContains(t, err, td.wantErr)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	levelSourceLabels       = "labels"
+	levelSourceConventional = "conventional-commits"
+	levelSourceMixed        = "mixed"
+)
+
+var (
+	conventionalSubjectRE  = regexp.MustCompile(`^(\w[\w-]*)(?:\([^()\r\n]*\))?(!)?: \S`)
+	breakingChangeFooterRE = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+)
+
+// conventionalCommitLabel returns the canonical label implied by a Conventional Commits message. Breaking changes
+// are semver:breaking, "feat" is semver:minor and "fix" is semver:patch. Other types are resolved as label aliases
+// and fall back to semver:none. ok is false when the message isn't a conventional commit.
+func conventionalCommitLabel(message string, aliases map[string]string) (label string, ok bool) {
+	subject, _, _ := strings.Cut(message, "\n")
+	matches := conventionalSubjectRE.FindStringSubmatch(strings.TrimSpace(subject))
+	if matches == nil {
+		return "", false
+	}
+	commitType, bang := strings.ToLower(matches[1]), matches[2]
+	switch {
+	case bang != "" || breakingChangeFooterRE.MatchString(message):
+		return labelBreaking, true
+	case commitType == "feat":
+		return labelMinor, true
+	case commitType == "fix":
+		return labelPatch, true
+	}
+	label = ResolveLabel(commitType, aliases)
+	if _, isLevel := labelLevel(label); !isLevel {
+		label = labelNone
+	}
+	return label, true
+}
+
+// applyLevelSource sets change levels from commit messages according to opts.LevelSource. With
+// levelSourceConventional each commit's level comes only from its message. With levelSourceMixed, pull requests with
+// level labels keep them and other commits get their level from their messages.
+func applyLevelSource(ctx context.Context, opts *getNextOptions, commits []gitCommit) ([]gitCommit, error) {
+	source := opts.levelSource()
+	if source == levelSourceLabels {
+		return commits, nil
+	}
+	local, err := listLocalCommits(ctx, opts.RepoDir, opts.Base, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+	localCommits := make(map[string]localCommit, len(local))
+	for _, lc := range local {
+		localCommits[lc.Sha] = lc
+	}
+	// commits from outside the range, like those of a checked PR
+	var missing []string
+	for _, commit := range commits {
+		if _, ok := localCommits[commit.Sha]; !ok {
+			missing = append(missing, commit.Sha)
+		}
+	}
+	local, err = readLocalCommits(ctx, opts.RepoDir, missing)
+	if err != nil {
+		return nil, fmt.Errorf("can't read commits outside of %s..%s. Are they fetched? %w", opts.Base, opts.Head, err)
+	}
+	for _, lc := range local {
+		localCommits[lc.Sha] = lc
+	}
+	result := make([]gitCommit, 0, len(commits))
+	for _, commit := range commits {
+		if source == levelSourceConventional {
+			commit.Pulls = nil
+		}
+		if source == levelSourceMixed && commit.hasLevelLabels() {
+			result = append(result, commit)
+			continue
+		}
+		lc := localCommits[commit.Sha]
+		label, ok := conventionalCommitLabel(lc.Message, opts.LabelAliases)
+		if !ok && len(commit.Pulls) == 0 {
+			result = append(result, commit)
+			continue
+		}
+		if !ok {
+			label = labelNone
+		}
+		level, _ := labelLevel(label)
+		if len(commit.Pulls) == 0 {
+			commit.Pulls = ghPulls{{Commit: commit.Sha, Title: lc.subject()}}
+		}
+		pulls := make(ghPulls, len(commit.Pulls))
+		for i, pull := range commit.Pulls {
+			pull.LevelLabels = []string{label}
+			pull.ChangeLevel = level
+			pulls[i] = pull
+		}
+		commit.Pulls = pulls
+		result = append(result, commit)
+	}
+	return result, nil
+}
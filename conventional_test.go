@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github"
+	"github.com/willabides/release-train/v3/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_conventionalCommitLabel(t *testing.T) {
+	aliases := map[string]string{"perf": labelPatch}
+	for _, td := range []struct {
+		message string
+		want    string
+		wantOK  bool
+	}{
+		{message: "feat: add foo", want: labelMinor, wantOK: true},
+		{message: "feat(api): add foo", want: labelMinor, wantOK: true},
+		{message: "fix: foo", want: labelPatch, wantOK: true},
+		{message: "Fix: foo", want: labelPatch, wantOK: true},
+		{message: "feat!: remove foo", want: labelBreaking, wantOK: true},
+		{message: "refactor(api)!: rename foo", want: labelBreaking, wantOK: true},
+		{message: "feat: rename foo\n\nBREAKING CHANGE: foo is now bar", want: labelBreaking, wantOK: true},
+		{message: "fix: foo\n\nBREAKING-CHANGE: foo is gone", want: labelBreaking, wantOK: true},
+		{message: "perf: faster foo", want: labelPatch, wantOK: true},
+		{message: "chore: update deps", want: labelNone, wantOK: true},
+		{message: "add foo", wantOK: false},
+		{message: "feat:add foo", wantOK: false},
+		{message: "Merge pull request #1 from foo/bar", wantOK: false},
+	} {
+		t.Run(td.message, func(t *testing.T) {
+			got, ok := conventionalCommitLabel(td.message, aliases)
+			require.Equal(t, td.wantOK, ok)
+			require.Equal(t, td.want, got)
+		})
+	}
+}
+
+func Test_getNext_levelSource(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	mustRunCmd(t, dir, "sh", "-c", `
+git init -q
+git config user.name 'tester'
+git config user.email 'tester'
+git commit -q --allow-empty -m "first"
+git tag v1.0.0
+git commit -q --allow-empty -m "fix: foo"
+git commit -q --allow-empty -m "feat: bar"
+git commit -q --allow-empty -m "docs: baz"
+`)
+	revs := strings.Split(mustRunCmd(t, dir, "git", "rev-list", "v1.0.0..HEAD"), "\n")
+	docsSha, featSha, fixSha := revs[0], revs[1], revs[2]
+	mergeSha := "4aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	opts := func(levelSource string, gh GithubClient) *getNextOptions {
+		return &getNextOptions{
+			GithubClient: gh,
+			Repo:         "orgName/repoName",
+			RepoDir:      dir,
+			LevelSource:  levelSource,
+			PrevVersion:  "1.0.0",
+			Base:         "v1.0.0",
+			Head:         docsSha,
+		}
+	}
+
+	t.Run("conventional-commits", func(t *testing.T) {
+		t.Parallel()
+		got, err := getNext(t.Context(), opts(levelSourceConventional, nil))
+		require.NoError(t, err)
+		require.Equal(t, &versionChange{
+			PreviousVersion: *semver.MustParse("1.0.0"),
+			NextVersion:     *semver.MustParse("1.1.0"),
+			ChangeLevel:     changeLevelMinor,
+		}, got)
+	})
+
+	t.Run("conventional-commits with check-pr", func(t *testing.T) {
+		t.Parallel()
+		prSha := mustRunCmd(t, dir, "sh", "-c", `git commit-tree -p HEAD -m "feat!: remove foo" HEAD^{tree}`)
+		gh := mocks.NewMockGithubClient(gomock.NewController(t))
+		gh.EXPECT().GetPullRequestCommits(gomock.Any(), "orgName", "repoName", 5).Return([]string{prSha}, nil)
+		o := opts(levelSourceConventional, gh)
+		o.CheckPR = 5
+		got, err := getNext(t.Context(), o)
+		require.NoError(t, err)
+		require.Equal(t, &versionChange{
+			PreviousVersion: *semver.MustParse("1.0.0"),
+			NextVersion:     *semver.MustParse("2.0.0"),
+			ChangeLevel:     changeLevelMajor,
+		}, got)
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		t.Parallel()
+		gh := mocks.NewMockGithubClient(gomock.NewController(t))
+		gh.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v1.0.0", docsSha, 0).Return(
+			&github.CommitComparison{AheadBy: 3}, nil,
+		)
+		gh.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, docsSha, 0).Return(
+			&github.CommitComparison{AheadBy: 1}, nil,
+		)
+		gh.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", fixSha).Return(
+			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha}}, nil,
+		)
+		// the label overrides the commit message
+		gh.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", featSha).Return(
+			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelPatch}}}, nil,
+		)
+		gh.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", docsSha).Return(nil, nil)
+		got, err := getNext(t.Context(), opts(levelSourceMixed, gh))
+		require.NoError(t, err)
+		require.Equal(t, &versionChange{
+			PreviousVersion: *semver.MustParse("1.0.0"),
+			NextVersion:     *semver.MustParse("1.0.1"),
+			ChangeLevel:     changeLevelPatch,
+		}, got)
+	})
+}
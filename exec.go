@@ -8,8 +8,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 )
 
@@ -24,6 +26,7 @@ func asExitErr(err error) *exec.ExitError {
 type runCmdOpts struct {
 	env    map[string]string
 	dir    string
+	stdin  io.Reader
 	stdout io.Writer
 	stderr io.Writer
 	noLog  bool
@@ -39,6 +42,7 @@ func runCmd(ctx context.Context, opts *runCmdOpts, command string, args ...strin
 	}
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Dir = opts.dir
+	cmd.Stdin = opts.stdin
 	cmd.Env = os.Environ()
 	for k, v := range opts.env {
 		addCmdEnv(cmd, k, v)
@@ -99,23 +103,53 @@ func runCmdHandleLines(
 	return nil
 }
 
-func getGithubRepoFromRemote(ctx context.Context, dir, remote string) (string, error) {
+// getGithubRepoFromRemote returns the repository path and host of a remote's url. The host is empty for local remotes.
+func getGithubRepoFromRemote(ctx context.Context, dir, remote string) (repo, host string, _ error) {
 	orig, err := runCmd(ctx, &runCmdOpts{
 		dir: dir,
 	}, "git", "remote", "get-url", remote)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	orig = strings.TrimSpace(orig)
-	remoteURL := strings.TrimSuffix(orig, ".git")
-	// trim everything before the last : for ssh remotes
-	remoteURL = remoteURL[strings.LastIndex(remoteURL, ":")+1:]
-	parts := strings.Split(remoteURL, "/")
+	return parseRemoteURL(strings.TrimSpace(orig))
+}
+
+// parseRemoteURL returns the repository path and host of a remote url. The path is the whole path on the host, which
+// is more than owner/repo for GitLab projects in subgroups. For local remotes it is the last two path segments.
+func parseRemoteURL(orig string) (repo, host string, _ error) {
+	remotePath := orig
+	switch {
+	case strings.Contains(orig, "://"):
+		u, err := url.Parse(orig)
+		if err != nil {
+			return "", "", err
+		}
+		host, remotePath = u.Hostname(), u.Path
+	case strings.Contains(orig, ":"):
+		// scp-like ssh remotes such as git@github.com:owner/repo.git
+		host, remotePath, _ = strings.Cut(orig, ":")
+		host = host[strings.LastIndex(host, "@")+1:]
+	}
+	remotePath = strings.TrimSuffix(strings.TrimSuffix(remotePath, "/"), ".git")
+	parts := strings.Split(strings.TrimPrefix(remotePath, "/"), "/")
+	const partCount = 2
+	if len(parts) < partCount || slices.Contains(parts, "") {
+		return "", "", fmt.Errorf("remote url is not a properly formated github repo url: %s", orig)
+	}
+	if host == "" {
+		parts = parts[len(parts)-partCount:]
+	}
+	return strings.Join(parts, "/"), host, nil
+}
+
+// ownerRepo returns the last two segments of a repository path, which are the owner and name on GitHub and Gitea.
+func ownerRepo(repoPath string) string {
+	parts := strings.Split(repoPath, "/")
 	const partCount = 2
-	if len(parts) < partCount {
-		return "", fmt.Errorf("remote url is not a properly formated github repo url: %s", orig)
+	if len(parts) <= partCount {
+		return repoPath
 	}
-	return strings.Join(parts[len(parts)-partCount:], "/"), nil
+	return strings.Join(parts[len(parts)-partCount:], "/")
 }
 
 func addCmdEnv(cmd *exec.Cmd, key string, val any) {
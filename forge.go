@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+)
+
+const (
+	forgeAuto   = "auto"
+	forgeGithub = "github"
+	forgeGitlab = "gitlab"
+	forgeGitea  = "gitea"
+)
+
+// forgeFromHost guesses which forge hosts a remote. Hosts that don't look like GitLab or Gitea are assumed to be
+// GitHub or GitHub Enterprise Server.
+func forgeFromHost(host string) string {
+	for _, label := range strings.Split(strings.ToLower(host), ".") {
+		switch label {
+		case "gitlab":
+			return forgeGitlab
+		case "gitea", "forgejo", "codeberg":
+			return forgeGitea
+		}
+	}
+	return forgeGithub
+}
+
+// giteaAPIURL returns the default API URL for a Gitea instance at host.
+func giteaAPIURL(host string) string {
+	if host == "" {
+		return ""
+	}
+	return "https://" + host + "/api/v1"
+}
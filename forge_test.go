@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseRemoteURL(t *testing.T) {
+	t.Parallel()
+	for _, td := range []struct {
+		url  string
+		repo string
+		host string
+		err  string
+	}{
+		{url: "https://github.com/orgname/repo.git", repo: "orgname/repo", host: "github.com"},
+		{url: "https://token@codeberg.org:443/orgname/repo/", repo: "orgname/repo", host: "codeberg.org"},
+		{url: "ssh://git@gitea.example.com:2222/orgname/repo.git", repo: "orgname/repo", host: "gitea.example.com"},
+		{url: "git@gitlab.com:group/sub/repo.git", repo: "group/sub/repo", host: "gitlab.com"},
+		{url: "https://gitlab.example.com/group/sub/repo", repo: "group/sub/repo", host: "gitlab.example.com"},
+		{url: "/tmp/orgname/repo", repo: "orgname/repo"},
+		{url: "repo", err: "remote url is not a properly formated github repo url: repo"},
+		{url: "https://github.com/repo", err: "remote url is not a properly formated github repo url"},
+	} {
+		t.Run(td.url, func(t *testing.T) {
+			t.Parallel()
+			repo, host, err := parseRemoteURL(td.url)
+			if td.err != "" {
+				require.ErrorContains(t, err, td.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.repo, repo)
+			require.Equal(t, td.host, host)
+		})
+	}
+}
+
+func Test_ownerRepo(t *testing.T) {
+	t.Parallel()
+	for repoPath, want := range map[string]string{
+		"orgname/repo":     "orgname/repo",
+		"git/orgname/repo": "orgname/repo",
+		"":                 "",
+	} {
+		require.Equal(t, want, ownerRepo(repoPath), repoPath)
+	}
+}
+
+func Test_forgeFromHost(t *testing.T) {
+	t.Parallel()
+	for host, want := range map[string]string{
+		"":                   forgeGithub,
+		"github.com":         forgeGithub,
+		"ghe.example.com":    forgeGithub,
+		"gitlab.com":         forgeGitlab,
+		"GitLab.example.com": forgeGitlab,
+		"codeberg.org":       forgeGitea,
+		"gitea.com":          forgeGitea,
+		"git.forgejo.org":    forgeGitea,
+		"mygitlab.example":   forgeGithub,
+	} {
+		require.Equal(t, want, forgeFromHost(host), host)
+	}
+}
@@ -15,7 +15,35 @@ type GithubClient interface {
 	CreateRelease(ctx context.Context, owner, repo, tag, body string, prerelease bool) (*github.RepoRelease, error)
 	UploadAsset(ctx context.Context, uploadURL, filename string) error
 	DeleteRelease(ctx context.Context, owner, repo string, id int64) error
-	PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) error
+	PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) (*github.RepoRelease, error)
+	ListReleases(ctx context.Context, owner, repo string) ([]github.RepoRelease, error)
 	GetPullRequest(ctx context.Context, owner, repo string, number int) (*github.BasePull, error)
 	GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]string, error)
 }
+
+// BatchPullLister is implemented by GithubClients that can look up the merged pulls for many commits at once. The
+// result is keyed by commit sha. It returns github.ErrGraphQLUnsupported when the server can't do the lookup, in which
+// case commits are looked up one at a time with ListMergedPullsForCommit.
+type BatchPullLister interface {
+	ListMergedPullsForCommits(ctx context.Context, owner, repo string, shas []string) (map[string][]github.BasePull, error)
+}
+
+// ReleasePRClient is implemented by GithubClients that can maintain a release pull request. Commits and branches are
+// created through the API, so no push access is needed for them.
+type ReleasePRClient interface {
+	CreateCommit(ctx context.Context, owner, repo string, commit *github.NewCommit) (string, error)
+	UpdateBranch(ctx context.Context, owner, repo, branch, sha string) error
+	FindOpenPullRequest(ctx context.Context, owner, repo, head, base string) (*github.BasePull, error)
+	CreatePullRequest(ctx context.Context, owner, repo string, pull *github.NewPull) (*github.BasePull, error)
+	UpdatePullRequest(ctx context.Context, owner, repo string, number int, title, body string) error
+}
+
+// GitDataClient is implemented by GithubClients that can create tags and commits with the Git Data API. --api-push
+// uses it in place of git push.
+type GitDataClient interface {
+	CreateCommit(ctx context.Context, owner, repo string, commit *github.NewCommit) (string, error)
+	CreateTag(ctx context.Context, owner, repo string, tag *github.NewTag) error
+	DeleteTag(ctx context.Context, owner, repo, tag string) error
+	FastForwardBranch(ctx context.Context, owner, repo, branch, sha string) error
+	MoveTag(ctx context.Context, owner, repo, tag, sha string) error
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const semverTrailer = "Semver"
+
+var (
+	pullSuffixRE = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+	// pullNumberREs match the subjects of GitHub merge commits and squash merges
+	pullNumberREs = []*regexp.Regexp{
+		regexp.MustCompile(`^Merge pull request #(\d+) from `),
+		pullSuffixRE,
+	}
+	gitlabMergeRequestRE = regexp.MustCompile(`(?m)^See merge request \S+!(\d+)$`)
+)
+
+// localCommit is a commit read from the local git history.
+type localCommit struct {
+	Sha      string
+	Message  string
+	Trailers []string
+}
+
+// subject returns the first line of the commit message.
+func (c localCommit) subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return strings.TrimSpace(subject)
+}
+
+// title returns the title of the pull request that a merge or squash commit merged. For other commits it is the
+// subject.
+func (c localCommit) title() string {
+	subject := c.subject()
+	if strings.HasPrefix(subject, "Merge ") {
+		// GitHub and GitLab merge commits have the title on the first line of the body
+		_, body, _ := strings.Cut(c.Message, "\n")
+		for line := range strings.Lines(body) {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "See merge request ") {
+				return line
+			}
+		}
+	}
+	return pullSuffixRE.ReplaceAllString(subject, "")
+}
+
+// pullNumber returns the number of the pull request this commit merged, or 0 if the message doesn't look like a
+// merge or squash commit created by GitHub or GitLab.
+func (c localCommit) pullNumber() int {
+	for _, re := range pullNumberREs {
+		number := submatchNumber(re, c.subject())
+		if number != 0 {
+			return number
+		}
+	}
+	// GitLab merge commits
+	return submatchNumber(gitlabMergeRequestRE, c.Message)
+}
+
+// submatchNumber returns the first submatch of re in text as an int or 0 if there is no match.
+func submatchNumber(re *regexp.Regexp, text string) int {
+	matches := re.FindStringSubmatch(text)
+	if matches == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// trailerLabels converts the values of Semver trailers to labels. "Semver: minor" is equivalent to the label
+// semver:minor. "major" is accepted as a synonym for "breaking". It returns an error for values that aren't a change
+// level, stable or prerelease.
+func (c localCommit) trailerLabels() ([]string, error) {
+	labels := make([]string, 0, len(c.Trailers))
+	for _, val := range c.Trailers {
+		val = strings.TrimSpace(val)
+		label := strings.ToLower(val)
+		if label == "major" {
+			label = "breaking"
+		}
+		label = "semver:" + label
+		_, isLevel := labelLevel(label)
+		isPre, _ := checkPrereleaseLabel(label, nil)
+		if !isLevel && !isPre && label != labelStable {
+			return nil, fmt.Errorf("commit %s has an unknown %s trailer value: %q", c.Sha, semverTrailer, val)
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// listLocalCommits returns the commits reachable from head but not from base, newest first.
+func listLocalCommits(ctx context.Context, dir, base, head string) ([]localCommit, error) {
+	return gitLogCommits(ctx, dir, base+".."+head)
+}
+
+// readLocalCommits returns the commits with the given shas. They don't need to be reachable from any ref.
+func readLocalCommits(ctx context.Context, dir string, shas []string) ([]localCommit, error) {
+	if len(shas) == 0 {
+		return nil, nil
+	}
+	return gitLogCommits(ctx, dir, append([]string{"--no-walk"}, shas...)...)
+}
+
+// gitLogCommits returns the commits git log lists for revs.
+func gitLogCommits(ctx context.Context, dir string, revs ...string) ([]localCommit, error) {
+	const (
+		fieldSep  = "\x1f"
+		recordSep = "\x1e"
+	)
+	format := strings.Join([]string{
+		"%H",
+		"%B",
+		"%(trailers:key=" + semverTrailer + ",valueonly,separator=%x2C)",
+	}, "%x1f") + "%x1e"
+	args := append([]string{"log", "--format=" + format}, revs...)
+	out, err := runCmd(ctx, &runCmdOpts{dir: dir, noLog: true}, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []localCommit
+	for _, record := range strings.Split(out, recordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, fieldSep)
+		const fieldCount = 3
+		if len(fields) != fieldCount {
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+		commit := localCommit{
+			Sha:     fields[0],
+			Message: strings.TrimSpace(fields[1]),
+		}
+		for _, trailer := range strings.Split(fields[2], ",") {
+			trailer = strings.TrimSpace(trailer)
+			if trailer != "" {
+				commit.Trailers = append(commit.Trailers, trailer)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// listCommitShas returns the shas of commits reachable from head but not from base, oldest first.
+func listCommitShas(ctx context.Context, dir, base, head string) ([]string, error) {
+	out, err := runCmd(ctx, &runCmdOpts{dir: dir, noLog: true}, "git", "rev-list", "--reverse", base+".."+head)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// listPathCommits returns the shas of commits reachable from head but not from base that change files in paths.
+// Merge commits are compared to their first parent, so a merge counts when it brings in changes to paths.
+func listPathCommits(ctx context.Context, dir, base, head string, paths []string) (map[string]bool, error) {
+	return pathCommits(ctx, dir, paths, base+".."+head)
+}
+
+// pathCommits is listPathCommits for the commits git log lists for revs.
+func pathCommits(ctx context.Context, dir string, paths []string, revs ...string) (map[string]bool, error) {
+	args := append([]string{
+		"-c", "core.quotePath=false", "log", "--format=%x1e%H", "--name-only", "--diff-merges=first-parent",
+	}, revs...)
+	out, err := runCmd(ctx, &runCmdOpts{dir: dir, noLog: true}, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]bool{}
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if lines[0] == "" {
+			continue
+		}
+		if slices.ContainsFunc(lines[1:], func(file string) bool { return touchesPaths(file, paths) }) {
+			result[lines[0]] = true
+		}
+	}
+	return result, nil
+}
+
+// filterPaths removes the shas of commits that don't change files in opts.Paths. Nothing is removed when opts.Paths
+// is empty.
+func filterPaths(ctx context.Context, opts *getNextOptions, shas []string) ([]string, error) {
+	if len(opts.Paths) == 0 {
+		return shas, nil
+	}
+	keep, err := listPathCommits(ctx, opts.RepoDir, opts.Base, opts.Head, opts.Paths)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(slices.Clone(shas), func(sha string) bool { return !keep[sha] }), nil
+}
+
+// filterLocalPaths is filterPaths for local commits.
+func filterLocalPaths(ctx context.Context, opts *getNextOptions, commits []localCommit) ([]localCommit, error) {
+	if len(opts.Paths) == 0 {
+		return commits, nil
+	}
+	keep, err := listPathCommits(ctx, opts.RepoDir, opts.Base, opts.Head, opts.Paths)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(commits, func(c localCommit) bool { return !keep[c.Sha] }), nil
+}
+
+// readLabelCache reads a file that maps pull request numbers to their labels.
+//
+//	"12": [semver:minor]
+//	"13": [semver:patch, documentation]
+func readLabelCache(filename string) (map[int][]string, error) {
+	if filename == "" {
+		return nil, nil //nolint:nilnil // an unset cache is empty
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	// keys are strings so that the file may also be JSON
+	var raw map[string][]string
+	err = yaml.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label cache %s: %w", filename, err)
+	}
+	cache := make(map[int][]string, len(raw))
+	for key, labels := range raw {
+		number, e := strconv.Atoi(key)
+		if e != nil {
+			return nil, fmt.Errorf("invalid label cache %s: %q is not a PR number", filename, key)
+		}
+		cache[number] = labels
+	}
+	return cache, nil
+}
+
+// localGitCommits returns the commits between opts.Base and opts.Head from local history without any pulls.
+func localGitCommits(ctx context.Context, opts *getNextOptions) ([]gitCommit, error) {
+	local, err := listLocalCommits(ctx, opts.RepoDir, opts.Base, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+	local, err = filterLocalPaths(ctx, opts, local)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gitCommit, len(local))
+	for i, lc := range local {
+		result[i].Sha = lc.Sha
+	}
+	return result, nil
+}
+
+// gitOnlyCommits builds gitCommits from local history without the GitHub API. A commit's pull request comes from
+// its merge or squash commit message and the pull request's labels come from the label cache. Semver trailers add
+// labels to the commit's pull request, or stand in for a pull request when the commit doesn't reference one.
+func gitOnlyCommits(ctx context.Context, opts *getNextOptions) ([]gitCommit, error) {
+	labelCache, err := readLabelCache(opts.LabelCache)
+	if err != nil {
+		return nil, err
+	}
+	local, err := listLocalCommits(ctx, opts.RepoDir, opts.Base, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+	local, err = filterLocalPaths(ctx, opts, local)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gitCommit, 0, len(local))
+	for _, lc := range local {
+		commit := gitCommit{Sha: lc.Sha}
+		number := lc.pullNumber()
+		var labels []string
+		if number != 0 {
+			labels = slices.Clone(labelCache[number])
+		}
+		var trailerLabels []string
+		trailerLabels, err = lc.trailerLabels()
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, trailerLabels...)
+		if number != 0 || len(labels) > 0 {
+			var p *ghPull
+			p, err = newPull(number, opts.LabelAliases, labels...)
+			if err != nil {
+				return nil, err
+			}
+			if number == 0 {
+				p.Commit = lc.Sha
+			}
+			p.Title = lc.title()
+			commit.Pulls = append(commit.Pulls, *p)
+		}
+		result = append(result, commit)
+	}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_localCommit_pullNumber(t *testing.T) {
+	for _, td := range []struct {
+		message string
+		want    int
+	}{
+		{message: "Merge pull request #12 from foo/bar\n\nadd bar", want: 12},
+		{message: "add bar (#13)", want: 13},
+		{message: "add bar (#13)\n\n* wip\n* more wip", want: 13},
+		{message: "Merge branch 'bar' into 'main'\n\nadd bar\n\nSee merge request foo/baz!14", want: 14},
+		{message: "fix #15", want: 0},
+		{message: "just a commit", want: 0},
+	} {
+		t.Run(td.message, func(t *testing.T) {
+			require.Equal(t, td.want, localCommit{Message: td.message}.pullNumber())
+		})
+	}
+}
+
+func Test_getNext_gitOnly(t *testing.T) {
+	t.Parallel()
+	setup := func(t *testing.T, script string) string {
+		t.Helper()
+		dir := t.TempDir()
+		mustRunCmd(t, dir, "sh", "-c", `
+git init -q
+git config user.name 'tester'
+git config user.email 'tester'
+git commit -q --allow-empty -m "first"
+git tag v1.0.0
+`+script)
+		return dir
+	}
+
+	labelCache := filepath.Join(t.TempDir(), "labels.yaml")
+	require.NoError(t, os.WriteFile(labelCache, []byte(`
+"1": [semver:patch]
+"2": [MinorAlias, documentation]
+"3": [documentation]
+`), 0o600))
+
+	for _, td := range []struct {
+		name    string
+		script  string
+		want    string
+		level   changeLevel
+		wantErr string
+	}{
+		{
+			name:   "no commits",
+			script: "",
+			want:   "1.0.0",
+			level:  changeLevelNone,
+		},
+		{
+			name:   "unreferenced commits are ignored",
+			script: `git commit -q --allow-empty -m "wip"`,
+			want:   "1.0.0",
+			level:  changeLevelNone,
+		},
+		{
+			name: "label cache",
+			script: `
+git commit -q --allow-empty -m "add foo (#1)"
+git commit -q --allow-empty -m "Merge pull request #2 from foo/bar"
+`,
+			want:  "1.1.0",
+			level: changeLevelMinor,
+		},
+		{
+			name: "trailer",
+			script: `
+git commit -q --allow-empty -m "add foo (#1)"
+git commit -q --allow-empty -m "remove foo" -m "Semver: major"
+`,
+			want:  "2.0.0",
+			level: changeLevelMajor,
+		},
+		{
+			name: "trailer on referenced pull",
+			script: `
+git commit -q --allow-empty -m "update docs (#3)" -m "semver: patch"
+`,
+			want:  "1.0.1",
+			level: changeLevelPatch,
+		},
+		{
+			name: "prerelease trailer",
+			script: `
+git commit -q --allow-empty -m "add foo" -m "Semver: minor
+Semver: prerelease:beta"
+`,
+			want:  "1.1.0-beta.0",
+			level: changeLevelMinor,
+		},
+		{
+			name:    "unknown trailer value",
+			script:  `git commit -q --allow-empty -m "add foo" -m "Semver: minr"`,
+			wantErr: `unknown Semver trailer value: "minr"`,
+		},
+		{
+			name:    "unlabeled pull",
+			script:  `git commit -q --allow-empty -m "update docs (#3)"`,
+			wantErr: "has no labels on associated pull requests: [#3]",
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			t.Parallel()
+			dir := setup(t, td.script)
+			head := mustRunCmd(t, dir, "git", "rev-parse", "HEAD")
+			got, err := getNext(t.Context(), &getNextOptions{
+				RepoDir:      dir,
+				GitOnly:      true,
+				LabelCache:   labelCache,
+				LabelAliases: map[string]string{"minoralias": labelMinor},
+				PrevVersion:  "1.0.0",
+				Base:         "v1.0.0",
+				Head:         head,
+			})
+			if td.wantErr != "" {
+				require.ErrorContains(t, err, td.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &versionChange{
+				PreviousVersion: *semver.MustParse("1.0.0"),
+				NextVersion:     *semver.MustParse(td.want),
+				ChangeLevel:     td.level,
+			}, got)
+		})
+	}
+}
+
+func Test_localCommit_title(t *testing.T) {
+	for _, td := range []struct {
+		message string
+		want    string
+	}{
+		{message: "Merge pull request #12 from foo/bar\n\nadd bar", want: "add bar"},
+		{message: "Merge pull request #12 from foo/bar", want: "Merge pull request #12 from foo/bar"},
+		{message: "add bar (#13)\n\n* wip", want: "add bar"},
+		{message: "Merge branch 'bar' into 'main'\n\nadd bar\n\nSee merge request foo/baz!14", want: "add bar"},
+		{message: "just a commit\n\nwith a body", want: "just a commit"},
+	} {
+		t.Run(td.message, func(t *testing.T) {
+			require.Equal(t, td.want, localCommit{Message: td.message}.title())
+		})
+	}
+}
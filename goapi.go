@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/apidiff"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// checkGoAPI fails a --check-pr run when the PR's level label is lower than its changes to the exported Go API need.
+// Incompatible changes need semver:breaking and compatible ones need semver:minor. The API is compared between the
+// merge base of the PR and the release target.
+func (o *Runner) checkGoAPI(ctx context.Context) error {
+	if !o.GoAPICheck || o.CheckPR == 0 {
+		return nil
+	}
+	if o.GithubClient == nil {
+		return errors.New("--go-api-check needs the GitHub API")
+	}
+	pr, err := o.GithubClient.GetPullRequest(ctx, o.repoOwner(), o.repoName(), o.CheckPR)
+	if err != nil {
+		return err
+	}
+	pull, err := newPull(o.CheckPR, o.LabelAliases, pr.Labels...)
+	if err != nil {
+		return err
+	}
+	if len(pull.LevelLabels) == 0 {
+		slog.Debug("skipping Go API check for a pull without a level label", slog.Int("pull", o.CheckPR))
+		return nil
+	}
+	if pr.BaseSha == "" {
+		return fmt.Errorf("can't find the base commit of pull #%d", o.CheckPR)
+	}
+	target, err := o.getReleaseTarget()
+	if err != nil {
+		return err
+	}
+	head, err := o.runCmd(ctx, nil, "git", "rev-parse", target)
+	if err != nil {
+		return err
+	}
+	base, err := o.runCmd(ctx, nil, "git", "merge-base", pr.BaseSha, strings.TrimSpace(head))
+	if err != nil {
+		return fmt.Errorf("can't find the merge base of pull #%d. Is %s fetched? %w", o.CheckPR, pr.BaseSha, err)
+	}
+	dir, err := goModuleDir(o.TagPrefix)
+	if err != nil {
+		dir = "."
+	}
+	report, err := o.goAPIChanges(ctx, strings.TrimSpace(base), strings.TrimSpace(head), dir)
+	if err != nil {
+		return err
+	}
+	level := apiChangeLevel(report)
+	if o.V0 {
+		level = min(level, changeLevelMinor)
+	}
+	slog.Debug("found Go API changes", slog.String("level", level.String()), slog.Any("changes", report.Changes))
+	if pull.ChangeLevel >= level {
+		return nil
+	}
+	return apiLevelError(pull, level, report)
+}
+
+// goAPIChanges compares the exported API of the module in dir at base and head. It reports nothing when either side
+// has no go.mod.
+func (o *Runner) goAPIChanges(ctx context.Context, base, head, dir string) (apidiff.Report, error) {
+	oldModule, err := o.loadGoAPI(ctx, base, dir)
+	if err != nil {
+		return apidiff.Report{}, err
+	}
+	newModule, err := o.loadGoAPI(ctx, head, dir)
+	if err != nil {
+		return apidiff.Report{}, err
+	}
+	if oldModule == nil || newModule == nil {
+		slog.Debug("skipping Go API check without go.mod", slog.String("dir", dir))
+		return apidiff.Report{}, nil
+	}
+	report := apidiff.ModuleChanges(oldModule, newModule)
+	slices.SortFunc(report.Changes, func(a, b apidiff.Change) int {
+		if a.Compatible != b.Compatible {
+			if a.Compatible {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Message, b.Message)
+	})
+	return report, nil
+}
+
+// loadGoAPI type checks the public packages of the module in dir at rev in a temporary worktree. Packages named main
+// and packages in internal directories are skipped because other modules can't import them.
+func (o *Runner) loadGoAPI(ctx context.Context, rev, dir string) (*apidiff.Module, error) {
+	goMod, err := o.readTargetFile(ctx, rev, path.Join(dir, "go.mod"))
+	if err != nil || goMod == "" {
+		return nil, err
+	}
+	worktree := filepath.Join(o.TempDir, "go-api-"+rev)
+	_, err = o.runCmd(ctx, nil, "git", "worktree", "add", "--quiet", "--detach", worktree, rev)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, e := o.runCmd(ctx, nil, "git", "worktree", "remove", "--force", worktree)
+		if e != nil {
+			slog.Warn("failed to remove worktree", slog.String("worktree", worktree), slog.Any("error", e))
+		}
+	}()
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Dir:     filepath.Join(worktree, dir),
+		// Type check from source because export data from a newer toolchain can be unreadable.
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+	}, "./...")
+	if err != nil {
+		return nil, err
+	}
+	module := apidiff.Module{Path: modfile.ModulePath([]byte(goMod))}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("can't load Go package %s at %s: %v", pkg.PkgPath, rev, pkg.Errors[0])
+		}
+		if pkg.Name == "main" || slices.Contains(strings.Split(pkg.PkgPath, "/"), "internal") {
+			continue
+		}
+		module.Packages = append(module.Packages, pkg.Types)
+	}
+	return &module, nil
+}
+
+// apiChangeLevel returns the change level needed for report.
+func apiChangeLevel(report apidiff.Report) changeLevel {
+	level := changeLevelNone
+	for _, change := range report.Changes {
+		if !change.Compatible {
+			return changeLevelMajor
+		}
+		level = changeLevelMinor
+	}
+	return level
+}
+
+// apiLevelError explains why pull needs level. It lists the changes that need level.
+func apiLevelError(pull *ghPull, level changeLevel, report apidiff.Report) error {
+	want := labelMinor
+	if level == changeLevelMajor {
+		want = labelBreaking
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "pull #%d is labeled %s, but its Go API changes need %s:",
+		pull.Number, strings.Join(pull.LevelLabels, ", "), want)
+	for _, change := range report.Changes {
+		if change.Compatible == (level == changeLevelMajor) {
+			continue
+		}
+		fmt.Fprintf(&msg, "\n  %s", change.Message)
+	}
+	return errors.New(msg.String())
+}
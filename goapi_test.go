@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
+)
+
+func TestRunner_checkGoAPI(t *testing.T) {
+	t.Parallel()
+	origin := t.TempDir()
+	clone := t.TempDir()
+	mustRunCmd(t, origin, "sh", "-c", `
+git init -q -b main
+git config user.name 'tester'
+git config user.email 'tester'
+mkdir -p internal/x cmd/foo
+echo 'module example.com/foo' > go.mod
+printf 'package foo\n\nfunc Foo() {}\n\nfunc Bar(int) {}\n' > foo.go
+printf 'package x\n\nfunc X() {}\n' > internal/x/x.go
+printf 'package main\n\nfunc Main() {}\n\nfunc main() {}\n' > cmd/foo/main.go
+git add .
+git commit -q -m "first"
+git checkout -q -b internal
+printf 'package x\n' > internal/x/x.go
+printf 'package main\n\nfunc main() {}\n' > cmd/foo/main.go
+git commit -q -am "change internal and main packages"
+git checkout -q -b minor main
+printf '\nfunc Baz() {}\n' >> foo.go
+git commit -q -am "add Baz"
+git checkout -q -b breaking minor
+printf 'package foo\n\nfunc Foo() {}\n\nfunc Bar(string) {}\n\nfunc Baz() {}\n' > foo.go
+mkdir qux
+printf 'package qux\n' > qux/qux.go
+git add qux
+git commit -q -am "change Bar"
+git checkout -q main
+git commit -q --allow-empty -m "after the PRs"
+`)
+	mustRunCmd(t, clone, "git", "clone", "-q", origin, ".")
+	main := mustRunCmd(t, clone, "git", "rev-parse", "origin/main")
+
+	server := githubtest.NewServer("token")
+	t.Cleanup(server.Close)
+	repo := server.AddRepo("orgName", "repoName", main)
+	repo.Refs["refs/heads/main"] = main
+	client, err := github.NewClient(server.APIURL, "token", "release-train/test")
+	require.NoError(t, err)
+
+	for i, td := range []struct {
+		name    string
+		branch  string
+		labels  []string
+		v0      bool
+		wantErr string
+	}{
+		{
+			name:   "internal and main packages",
+			branch: "internal",
+			labels: []string{labelNone},
+		},
+		{
+			name:   "compatible",
+			branch: "minor",
+			labels: []string{labelMinor},
+		},
+		{
+			name:    "compatible labeled patch",
+			branch:  "minor",
+			labels:  []string{labelPatch},
+			wantErr: "pull #%d is labeled semver:patch, but its Go API changes need semver:minor:\n  Baz: added",
+		},
+		{
+			name:   "incompatible",
+			branch: "breaking",
+			labels: []string{labelBreaking},
+		},
+		{
+			name:   "incompatible v0",
+			branch: "breaking",
+			labels: []string{labelMinor},
+			v0:     true,
+		},
+		{
+			name:   "incompatible labeled minor",
+			branch: "breaking",
+			labels: []string{labelMinor},
+			wantErr: "pull #%d is labeled semver:minor, but its Go API changes need semver:breaking:\n" +
+				"  Bar: changed from func(int) to func(string)",
+		},
+		{
+			name:   "no level label",
+			branch: "breaking",
+		},
+	} {
+		// The worktrees are added to the same clone, so these don't run in parallel.
+		number := i + 1
+		t.Run(td.name, func(t *testing.T) {
+			server.AddPull(repo, githubtest.Pull{
+				Number: number,
+				Labels: td.labels,
+				Head:   td.branch,
+				Base:   "main",
+				Open:   true,
+			})
+			runner := &Runner{
+				CheckoutDir:  clone,
+				Ref:          "origin/" + td.branch,
+				TagPrefix:    "v",
+				Repo:         "orgName/repoName",
+				TempDir:      t.TempDir(),
+				GithubClient: client,
+				CheckPR:      number,
+				V0:           td.v0,
+				GoAPICheck:   true,
+			}
+			err := runner.checkGoAPI(t.Context())
+			if td.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, fmt.Sprintf(td.wantErr, number))
+		})
+	}
+}
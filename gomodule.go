@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// goModuleDir returns the directory of the go.mod for tags with tagPrefix. Go tags nested modules with their directory
+// as the prefix, so "v" is the root module and "tools/v" is the module in tools.
+func goModuleDir(tagPrefix string) (string, error) {
+	dir, ok := strings.CutSuffix(tagPrefix, "v")
+	if !ok || (dir != "" && !strings.HasSuffix(dir, "/")) {
+		return "", fmt.Errorf(`--go-module needs a tag prefix like "v" or "DIR/v", but it is %q`, tagPrefix)
+	}
+	if dir == "" {
+		return ".", nil
+	}
+	return strings.TrimSuffix(dir, "/"), nil
+}
+
+// checkGoModule checks that the module path in go.mod at the release target works with a major release. Major
+// versions 2 and up need the module path to end with the major version, and lower ones can't have a major version
+// suffix.
+func (o *Runner) checkGoModule(ctx context.Context, result *Result) error {
+	if !o.GoModule || result.ReleaseVersion == nil || result.ChangeLevel != changeLevelMajor {
+		return nil
+	}
+	dir, err := goModuleDir(o.TagPrefix)
+	if err != nil {
+		return err
+	}
+	target, err := o.getReleaseTarget()
+	if err != nil {
+		return err
+	}
+	goMod := path.Join(dir, "go.mod")
+	content, err := o.readTargetFile(ctx, target, goMod)
+	if err != nil {
+		return err
+	}
+	modPath := modfile.ModulePath([]byte(content))
+	if modPath == "" {
+		return fmt.Errorf("--go-module is set, but %s at %s doesn't declare a module", goMod, target)
+	}
+	return checkModuleMajor(modPath, goMod, result.ReleaseVersion.Major())
+}
+
+// checkModuleMajor returns an error explaining how to fix modPath when it doesn't work with major.
+func checkModuleMajor(modPath, goMod string, major uint64) error {
+	prefix, pathMajor, ok := module.SplitPathVersion(modPath)
+	if !ok {
+		return fmt.Errorf("%s has an invalid module path %q", goMod, modPath)
+	}
+	if module.CheckPathMajor(fmt.Sprintf("v%d.0.0", major), pathMajor) == nil {
+		return nil
+	}
+	want := prefix
+	switch {
+	case strings.HasPrefix(modPath, "gopkg.in/"):
+		want = fmt.Sprintf("%s.v%d", prefix, max(major, 1))
+	case major >= 2:
+		want = fmt.Sprintf("%s/v%d", prefix, major)
+	}
+	return fmt.Errorf(
+		"the module path in %s is %s, which can't be released as major version %d. Change it to %s",
+		goMod, modPath, major, want,
+	)
+}
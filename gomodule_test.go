@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_goModuleDir(t *testing.T) {
+	t.Parallel()
+	for prefix, want := range map[string]string{
+		"v":             ".",
+		"tools/v":       "tools",
+		"cmd/foo/bar/v": "cmd/foo/bar",
+	} {
+		got, err := goModuleDir(prefix)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	for _, prefix := range []string{"", "tools-v", "release-"} {
+		_, err := goModuleDir(prefix)
+		require.Error(t, err, prefix)
+	}
+}
+
+func Test_checkModuleMajor(t *testing.T) {
+	t.Parallel()
+	for _, td := range []struct {
+		modPath string
+		major   uint64
+		wantErr string
+	}{
+		{modPath: "example.com/foo", major: 1},
+		{modPath: "example.com/foo/v2", major: 2},
+		{modPath: "example.com/foo/tools/v3", major: 3},
+		{modPath: "gopkg.in/yaml.v3", major: 3},
+		{
+			modPath: "example.com/foo",
+			major:   2,
+			wantErr: "the module path in go.mod is example.com/foo, which can't be released as major version 2. Change it to example.com/foo/v2",
+		},
+		{
+			modPath: "example.com/foo/v2",
+			major:   3,
+			wantErr: "the module path in go.mod is example.com/foo/v2, which can't be released as major version 3. Change it to example.com/foo/v3",
+		},
+		{
+			modPath: "example.com/foo/v2",
+			major:   1,
+			wantErr: "the module path in go.mod is example.com/foo/v2, which can't be released as major version 1. Change it to example.com/foo",
+		},
+		{
+			modPath: "gopkg.in/yaml.v2",
+			major:   3,
+			wantErr: "the module path in go.mod is gopkg.in/yaml.v2, which can't be released as major version 3. Change it to gopkg.in/yaml.v3",
+		},
+	} {
+		err := checkModuleMajor(td.modPath, "go.mod", td.major)
+		if td.wantErr == "" {
+			require.NoError(t, err, td.modPath)
+			continue
+		}
+		require.EqualError(t, err, td.wantErr)
+	}
+}
+
+func TestRunner_goModule(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, modPath string) (clone string) {
+		t.Helper()
+		_, clone = setupTestRepo(t, "tools/v1.0.0", `
+mkdir tools
+echo 'module `+modPath+`' > tools/go.mod
+echo 'package tools' > tools/tools.go
+git add tools
+git commit -q -m "feat!: break tools"
+`)
+		return clone
+	}
+
+	newRunner := func(t *testing.T, clone string) *Runner {
+		t.Helper()
+		runner := newTestRunner(t, clone)
+		runner.TagPrefix = "tools/v"
+		runner.GoModule = true
+		return runner
+	}
+
+	wantErr := "the module path in tools/go.mod is example.com/foo/tools, which can't be released as major version 2. Change it to example.com/foo/tools/v2"
+
+	t.Run("release", func(t *testing.T) {
+		t.Parallel()
+		clone := setup(t, "example.com/foo/tools")
+		_, err := newRunner(t, clone).run(t.Context())
+		require.EqualError(t, err, wantErr)
+		require.Empty(t, mustRunCmd(t, clone, "git", "tag", "--list", "tools/v2.0.0"))
+	})
+
+	t.Run("check-pr", func(t *testing.T) {
+		t.Parallel()
+		clone := setup(t, "example.com/foo/tools")
+		runner := newRunner(t, clone)
+		gh := mocks.NewMockGithubClient(gomock.NewController(t))
+		gh.EXPECT().GetPullRequestCommits(gomock.Any(), "orgName", "repoName", 1).Return(nil, nil)
+		runner.GitOnly = false
+		runner.GithubClient = gh
+		runner.CheckPR = 1
+		_, err := runner.run(t.Context())
+		require.EqualError(t, err, wantErr)
+	})
+
+	t.Run("matching module path", func(t *testing.T) {
+		t.Parallel()
+		clone := setup(t, "example.com/foo/tools/v2")
+		got, err := newRunner(t, clone).run(t.Context())
+		require.NoError(t, err)
+		require.True(t, got.CreatedTag)
+		require.Equal(t, "tools/v2.0.0", got.ReleaseTag)
+	})
+}
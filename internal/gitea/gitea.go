@@ -0,0 +1,376 @@
+// Package gitea implements release-train's forge client for Gitea and Forgejo using their REST API.
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/willabides/release-train/v3/internal/github"
+)
+
+// Client is a Gitea or Forgejo client.
+type Client struct {
+	baseURL    string
+	token      string
+	userAgent  string
+	httpClient *http.Client
+}
+
+type pullRequest struct {
+	Number         int    `json:"number"`
+	Title          string `json:"title"`
+	HTMLURL        string `json:"html_url"`
+	Merged         bool   `json:"merged"`
+	MergeCommitSha string `json:"merge_commit_sha"`
+	User           struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Base struct {
+		Sha string `json:"sha"`
+	} `json:"base"`
+}
+
+func (p *pullRequest) basePull() github.BasePull {
+	pull := github.BasePull{
+		Number:         p.Number,
+		MergeCommitSha: p.MergeCommitSha,
+		Labels:         make([]string, len(p.Labels)),
+		Title:          p.Title,
+		Author:         p.User.Login,
+		URL:            p.HTMLURL,
+		BaseSha:        p.Base.Sha,
+	}
+	for i, label := range p.Labels {
+		pull.Labels[i] = label.Name
+	}
+	return pull
+}
+
+type commit struct {
+	Sha string `json:"sha"`
+}
+
+type release struct {
+	ID         int64  `json:"id"`
+	HTMLURL    string `json:"html_url"`
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// NewClient returns a client for the API at baseURL, which is usually https://<host>/api/v1.
+func NewClient(baseURL, token, userAgent string) (*Client, error) {
+	if baseURL == "" {
+		return nil, errors.New("gitea API URL is required")
+	}
+	_, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		userAgent:  userAgent,
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+func (g *Client) repoURL(owner, repo string, elem ...string) string {
+	u := g.baseURL + "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	for _, e := range elem {
+		u += "/" + url.PathEscape(e)
+	}
+	return u
+}
+
+func (g *Client) do(ctx context.Context, method, reqURL, contentType string, body io.Reader, out any) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &apiError{
+			method: method,
+			url:    req.URL.Redacted(),
+			status: resp.Status,
+			code:   resp.StatusCode,
+			body:   string(bytes.TrimSpace(respBody)),
+		}
+	}
+	if out != nil {
+		err = json.Unmarshal(respBody, out)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, req.URL.Redacted(), err)
+		}
+	}
+	return resp, nil
+}
+
+func (g *Client) doJSON(ctx context.Context, method, reqURL string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	_, err = g.do(ctx, method, reqURL, "application/json", bytes.NewReader(body), out)
+	return err
+}
+
+// getPages gets every page of a list endpoint. Gitea reports the next page in the Link header.
+func getPages[T any](ctx context.Context, g *Client, reqURL string) ([]T, error) {
+	const pageSize = 50
+	var result []T
+	for page := 1; ; page++ {
+		query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(pageSize)}}
+		var items []T
+		resp, err := g.do(ctx, http.MethodGet, reqURL+"?"+query.Encode(), "", nil, &items)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+		if len(items) == 0 || !strings.Contains(resp.Header.Get("Link"), `rel="next"`) {
+			return result, nil
+		}
+	}
+}
+
+// ListMergedPullsForCommit returns the pull request that merged sha. Gitea only reports one pull request per commit.
+func (g *Client) ListMergedPullsForCommit(ctx context.Context, owner, repo, sha string) ([]github.BasePull, error) {
+	var pr pullRequest
+	_, err := g.do(ctx, http.MethodGet, g.repoURL(owner, repo, "commits", sha, "pull"), "", nil, &pr)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !pr.Merged || pr.MergeCommitSha == "" {
+		return nil, nil
+	}
+	return []github.BasePull{pr.basePull()}, nil
+}
+
+// CompareCommits returns a commit comparison that includes up to count commits. If count is -1, all commits are
+// included. If count is 0, no commits are included. BehindBy comes from comparing in the other direction.
+func (g *Client) CompareCommits(
+	ctx context.Context,
+	owner, repo, base, head string,
+	count int,
+) (*github.CommitComparison, error) {
+	ahead, err := g.compare(ctx, owner, repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	behind, err := g.compare(ctx, owner, repo, head, base)
+	if err != nil {
+		return nil, err
+	}
+	result := github.CommitComparison{
+		AheadBy:  len(ahead),
+		BehindBy: len(behind),
+	}
+	if count >= 0 && count < len(ahead) {
+		ahead = ahead[:count]
+	}
+	for _, c := range ahead {
+		result.Commits = append(result.Commits, c.Sha)
+	}
+	return &result, nil
+}
+
+func (g *Client) compare(ctx context.Context, owner, repo, base, head string) ([]commit, error) {
+	var comp struct {
+		Commits []commit `json:"commits"`
+	}
+	_, err := g.do(ctx, http.MethodGet, g.repoURL(owner, repo, "compare", base+"..."+head), "", nil, &comp)
+	if err != nil {
+		return nil, err
+	}
+	return comp.Commits, nil
+}
+
+// GenerateReleaseNotes returns a link to the comparison between the tags. Gitea doesn't generate release notes.
+func (g *Client) GenerateReleaseNotes(ctx context.Context, owner, repo, tag, prevTag string) (string, error) {
+	var r struct {
+		HTMLURL string `json:"html_url"`
+	}
+	_, err := g.do(ctx, http.MethodGet, g.repoURL(owner, repo), "", nil, &r)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("**Full Changelog**: %s/compare/%s...%s", r.HTMLURL, prevTag, tag), nil
+}
+
+// CreateRelease creates a draft release.
+func (g *Client) CreateRelease(
+	ctx context.Context,
+	owner, repo, tag, body string,
+	prerelease bool,
+) (*github.RepoRelease, error) {
+	var rel release
+	err := g.doJSON(ctx, http.MethodPost, g.repoURL(owner, repo, "releases"), map[string]any{
+		"tag_name":   tag,
+		"name":       tag,
+		"body":       body,
+		"draft":      true,
+		"prerelease": prerelease,
+	}, &rel)
+	if err != nil {
+		return nil, err
+	}
+	return &github.RepoRelease{
+		ID:        rel.ID,
+		UploadURL: g.repoURL(owner, repo, "releases", strconv.FormatInt(rel.ID, 10), "assets"),
+	}, nil
+}
+
+// UploadAsset uploads a file as a release attachment.
+func (g *Client) UploadAsset(ctx context.Context, uploadURL, filename string) error {
+	re := regexp.MustCompile(`/repos/[^/]+/[^/]+/releases/\d+/assets$`)
+	if !strings.HasPrefix(uploadURL, g.baseURL) || !re.MatchString(uploadURL) {
+		return fmt.Errorf("invalid upload url: %s", uploadURL)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	name := filepath.Base(filename)
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("attachment", name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return err
+	}
+	err = form.Close()
+	if err != nil {
+		return err
+	}
+	query := url.Values{"name": {name}}
+	_, err = g.do(ctx, http.MethodPost, uploadURL+"?"+query.Encode(), form.FormDataContentType(), &buf, nil)
+	return err
+}
+
+func (g *Client) DeleteRelease(ctx context.Context, owner, repo string, id int64) error {
+	_, err := g.do(ctx, http.MethodDelete, g.repoURL(owner, repo, "releases", strconv.FormatInt(id, 10)), "", nil, nil)
+	return err
+}
+
+// PublishRelease takes the release out of draft. Gitea shows the most recent release as latest, so makeLatest is
+// only validated.
+func (g *Client) PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) (*github.RepoRelease, error) {
+	if !slices.Contains([]string{"", "legacy", "true", "false"}, makeLatest) {
+		return nil, fmt.Errorf("invalid makeLatest value: %s", makeLatest)
+	}
+	var rel release
+	err := g.doJSON(ctx, http.MethodPatch, g.repoURL(owner, repo, "releases", strconv.FormatInt(id, 10)),
+		map[string]any{"draft": false}, &rel)
+	if err != nil {
+		return nil, err
+	}
+	return &github.RepoRelease{
+		ID:        rel.ID,
+		UploadURL: g.repoURL(owner, repo, "releases", strconv.FormatInt(rel.ID, 10), "assets"),
+		URL:       rel.HTMLURL,
+	}, nil
+}
+
+// ListReleases returns the repository's releases, including drafts when the token can see them.
+func (g *Client) ListReleases(ctx context.Context, owner, repo string) ([]github.RepoRelease, error) {
+	releases, err := getPages[release](ctx, g, g.repoURL(owner, repo, "releases"))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]github.RepoRelease, len(releases))
+	for i, rel := range releases {
+		result[i] = github.RepoRelease{
+			ID:         rel.ID,
+			UploadURL:  g.repoURL(owner, repo, "releases", strconv.FormatInt(rel.ID, 10), "assets"),
+			URL:        rel.HTMLURL,
+			Tag:        rel.TagName,
+			Draft:      rel.Draft,
+			Prerelease: rel.Prerelease,
+		}
+	}
+	return result, nil
+}
+
+func (g *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*github.BasePull, error) {
+	var pr pullRequest
+	_, err := g.do(ctx, http.MethodGet, g.repoURL(owner, repo, "pulls", strconv.Itoa(number)), "", nil, &pr)
+	if err != nil {
+		return nil, err
+	}
+	pull := pr.basePull()
+	return &pull, nil
+}
+
+func (g *Client) GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]string, error) {
+	commits, err := getPages[commit](ctx, g, g.repoURL(owner, repo, "pulls", strconv.Itoa(number), "commits"))
+	if err != nil {
+		return nil, err
+	}
+	shas := make([]string, len(commits))
+	for i, c := range commits {
+		shas[i] = c.Sha
+	}
+	return shas, nil
+}
+
+// apiError is an error response from the API.
+type apiError struct {
+	method string
+	url    string
+	status string
+	code   int
+	body   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.url, e.status, e.body)
+}
+
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.code == http.StatusNotFound
+}
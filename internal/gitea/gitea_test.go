@@ -0,0 +1,161 @@
+package gitea
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/gitea/giteatest"
+	"github.com/willabides/release-train/v3/internal/github"
+)
+
+const testToken = "secret"
+
+func newTestClient(t *testing.T, token string) (*Client, *giteatest.Server, *giteatest.Repo) {
+	t.Helper()
+	server := giteatest.NewServer(testToken)
+	t.Cleanup(server.Close)
+	repo := server.AddRepo("orgname", "repo", "base", "c0", "c1", "m1", "s2")
+	server.AddPull(repo, giteatest.Pull{
+		Number:         1,
+		Title:          "add foo",
+		Author:         "alice",
+		Labels:         []string{"semver:minor"},
+		Merged:         true,
+		MergeCommitSha: "m1",
+		Commits:        []string{"c0", "c1"},
+	})
+	server.AddPull(repo, giteatest.Pull{
+		Number:         2,
+		Title:          "fix bar",
+		Labels:         []string{"semver:patch"},
+		Merged:         true,
+		MergeCommitSha: "s2",
+		Commits:        make([]string, 120),
+	})
+	server.AddPull(repo, giteatest.Pull{Number: 3, Title: "wip", MergeCommitSha: "c1"})
+	client, err := NewClient(server.APIURL+"/", token, "release-train/test")
+	require.NoError(t, err)
+	return client, server, repo
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ListMergedPullsForCommit", func(t *testing.T) {
+		t.Parallel()
+		client, server, _ := newTestClient(t, testToken)
+		got, err := client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", "m1")
+		require.NoError(t, err)
+		require.Equal(t, []github.BasePull{{
+			Number:         1,
+			MergeCommitSha: "m1",
+			Labels:         []string{"semver:minor"},
+			Title:          "add foo",
+			Author:         "alice",
+			URL:            server.URL + "/orgname/repo/pulls/1",
+		}}, got)
+
+		got, err = client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", "c1")
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("CompareCommits", func(t *testing.T) {
+		t.Parallel()
+		client, _, _ := newTestClient(t, testToken)
+		got, err := client.CompareCommits(t.Context(), "orgname", "repo", "base", "m1", -1)
+		require.NoError(t, err)
+		require.Equal(t, &github.CommitComparison{AheadBy: 3, Commits: []string{"c0", "c1", "m1"}}, got)
+
+		got, err = client.CompareCommits(t.Context(), "orgname", "repo", "base", "m1", 2)
+		require.NoError(t, err)
+		require.Equal(t, &github.CommitComparison{AheadBy: 3, Commits: []string{"c0", "c1"}}, got)
+
+		got, err = client.CompareCommits(t.Context(), "orgname", "repo", "s2", "c1", 0)
+		require.NoError(t, err)
+		require.Equal(t, &github.CommitComparison{BehindBy: 2}, got)
+	})
+
+	t.Run("GetPullRequest", func(t *testing.T) {
+		t.Parallel()
+		client, server, _ := newTestClient(t, testToken)
+		got, err := client.GetPullRequest(t.Context(), "orgname", "repo", 2)
+		require.NoError(t, err)
+		require.Equal(t, &github.BasePull{
+			Number:         2,
+			MergeCommitSha: "s2",
+			Labels:         []string{"semver:patch"},
+			Title:          "fix bar",
+			URL:            server.URL + "/orgname/repo/pulls/2",
+		}, got)
+
+		_, err = client.GetPullRequest(t.Context(), "orgname", "repo", 99)
+		require.ErrorContains(t, err, "404 Not Found")
+	})
+
+	t.Run("GetPullRequestCommits", func(t *testing.T) {
+		t.Parallel()
+		client, _, _ := newTestClient(t, testToken)
+		got, err := client.GetPullRequestCommits(t.Context(), "orgname", "repo", 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"c0", "c1"}, got)
+
+		got, err = client.GetPullRequestCommits(t.Context(), "orgname", "repo", 2)
+		require.NoError(t, err)
+		require.Len(t, got, 120)
+	})
+
+	t.Run("GenerateReleaseNotes", func(t *testing.T) {
+		t.Parallel()
+		client, server, _ := newTestClient(t, testToken)
+		got, err := client.GenerateReleaseNotes(t.Context(), "orgname", "repo", "v1.1.0", "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "**Full Changelog**: "+server.URL+"/orgname/repo/compare/v1.0.0...v1.1.0", got)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		t.Parallel()
+		client, server, repo := newTestClient(t, testToken)
+		ctx := t.Context()
+		rel, err := client.CreateRelease(ctx, "orgname", "repo", "v1.1.0", "release notes", true)
+		require.NoError(t, err)
+		asset := filepath.Join(t.TempDir(), "foo.txt")
+		require.NoError(t, os.WriteFile(asset, []byte("foo\n"), 0o600))
+		require.NoError(t, client.UploadAsset(ctx, rel.UploadURL, asset))
+		require.ErrorContains(t, client.UploadAsset(ctx, "https://example.com/upload", asset), "invalid upload url")
+		require.True(t, server.Releases(repo)[0].Draft)
+		published, err := client.PublishRelease(ctx, "orgname", "repo", "true", rel.ID)
+		require.NoError(t, err)
+		require.Equal(t, server.URL+"/orgname/repo/releases/tag/v1.1.0", published.URL)
+		require.Equal(t, []giteatest.Release{{
+			ID:         rel.ID,
+			TagName:    "v1.1.0",
+			Name:       "v1.1.0",
+			Body:       "release notes",
+			Prerelease: true,
+			Assets:     map[string]string{"foo.txt": "foo\n"},
+		}}, server.Releases(repo))
+		_, err = client.PublishRelease(ctx, "orgname", "repo", "bogus", rel.ID)
+		require.ErrorContains(t, err, "invalid makeLatest")
+		releases, err := client.ListReleases(ctx, "orgname", "repo")
+		require.NoError(t, err)
+		require.Equal(t, []github.RepoRelease{{
+			ID:         rel.ID,
+			UploadURL:  rel.UploadURL,
+			URL:        published.URL,
+			Tag:        "v1.1.0",
+			Prerelease: true,
+		}}, releases)
+		require.NoError(t, client.DeleteRelease(ctx, "orgname", "repo", rel.ID))
+		require.Empty(t, server.Releases(repo))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		t.Parallel()
+		client, _, _ := newTestClient(t, "wrong")
+		_, err := client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", "m1")
+		require.ErrorContains(t, err, "401 Unauthorized")
+	})
+}
@@ -0,0 +1,358 @@
+// Package giteatest provides an in-process fake Gitea server for tests. It implements the endpoints used by
+// gitea.Client with the same quirks as Gitea, such as only finding pull requests by their merge commit.
+package giteatest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a fake Gitea server. APIURL is the URL to pass to gitea.NewClient.
+type Server struct {
+	*httptest.Server
+	APIURL string
+	// Token is the token required in requests. Any request is accepted when it is empty.
+	Token string
+
+	mu     sync.Mutex
+	repos  map[string]*Repo
+	lastID int64
+}
+
+// Repo is a repository on the fake server.
+type Repo struct {
+	Owner string
+	Name  string
+	// Commits is the repository's history, oldest first. Comparisons use positions in it.
+	Commits  []string
+	Pulls    []Pull
+	Releases []*Release
+}
+
+// Pull is a pull request.
+type Pull struct {
+	Number         int
+	Title          string
+	Author         string
+	Labels         []string
+	Merged         bool
+	MergeCommitSha string
+	Commits        []string
+}
+
+// Release is a release and the assets uploaded to it.
+type Release struct {
+	ID         int64
+	TagName    string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	Assets     map[string]string
+}
+
+// NewServer starts a fake server. Close it when done.
+func NewServer(token string) *Server {
+	s := &Server{
+		Token: token,
+		repos: map[string]*Repo{},
+	}
+	s.Server = httptest.NewServer(s.handler())
+	s.APIURL = s.URL + "/api/v1"
+	return s
+}
+
+// AddRepo adds a repository with a linear history.
+func (s *Server) AddRepo(owner, name string, commits ...string) *Repo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo := &Repo{Owner: owner, Name: name, Commits: commits}
+	s.repos[owner+"/"+name] = repo
+	return repo
+}
+
+// AddPull adds a pull request to a repository.
+func (s *Server) AddPull(repo *Repo, pull Pull) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo.Pulls = append(repo.Pulls, pull)
+}
+
+// Releases returns a copy of a repository's releases.
+func (s *Server) Releases(repo *Repo) []Release {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Release, len(repo.Releases))
+	for i, rel := range repo.Releases {
+		result[i] = *rel
+	}
+	return result
+}
+
+func (s *Server) htmlURL(repo *Repo) string {
+	return s.URL + "/" + repo.Owner + "/" + repo.Name
+}
+
+func (s *Server) pullJSON(repo *Repo, pull *Pull) map[string]any {
+	labels := make([]map[string]any, len(pull.Labels))
+	for i, label := range pull.Labels {
+		labels[i] = map[string]any{"name": label}
+	}
+	result := map[string]any{
+		"number":   pull.Number,
+		"title":    pull.Title,
+		"html_url": fmt.Sprintf("%s/pulls/%d", s.htmlURL(repo), pull.Number),
+		"merged":   pull.Merged,
+		"user":     map[string]any{"login": pull.Author},
+		"labels":   labels,
+	}
+	if pull.MergeCommitSha != "" {
+		result["merge_commit_sha"] = pull.MergeCommitSha
+	}
+	return result
+}
+
+type repoHandler func(w http.ResponseWriter, r *http.Request, repo *Repo)
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(pattern string, h repoHandler) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if s.Token != "" && r.Header.Get("Authorization") != "token "+s.Token {
+				writeError(w, http.StatusUnauthorized, "token is required")
+				return
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			repo := s.repos[r.PathValue("owner")+"/"+r.PathValue("repo")]
+			if repo == nil {
+				writeError(w, http.StatusNotFound, "repository not found")
+				return
+			}
+			h(w, r, repo)
+		})
+	}
+	handle("GET /api/v1/repos/{owner}/{repo}", s.getRepo)
+	handle("GET /api/v1/repos/{owner}/{repo}/commits/{sha}/pull", s.getCommitPull)
+	handle("GET /api/v1/repos/{owner}/{repo}/compare/{basehead}", s.compare)
+	handle("GET /api/v1/repos/{owner}/{repo}/pulls/{number}", s.getPull)
+	handle("GET /api/v1/repos/{owner}/{repo}/pulls/{number}/commits", s.getPullCommits)
+	handle("GET /api/v1/repos/{owner}/{repo}/releases", s.listReleases)
+	handle("POST /api/v1/repos/{owner}/{repo}/releases", s.createRelease)
+	handle("PATCH /api/v1/repos/{owner}/{repo}/releases/{id}", s.editRelease)
+	handle("DELETE /api/v1/repos/{owner}/{repo}/releases/{id}", s.deleteRelease)
+	handle("POST /api/v1/repos/{owner}/{repo}/releases/{id}/assets", s.uploadAsset)
+	return mux
+}
+
+func (s *Server) getRepo(w http.ResponseWriter, _ *http.Request, repo *Repo) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"full_name": repo.Owner + "/" + repo.Name,
+		"html_url":  s.htmlURL(repo),
+	})
+}
+
+func (s *Server) getCommitPull(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	for i := range repo.Pulls {
+		if repo.Pulls[i].Merged && repo.Pulls[i].MergeCommitSha == r.PathValue("sha") {
+			writeJSON(w, http.StatusOK, s.pullJSON(repo, &repo.Pulls[i]))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "pull request does not exist")
+}
+
+func (s *Server) compare(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	base, head, ok := strings.Cut(r.PathValue("basehead"), "...")
+	from := slices.Index(repo.Commits, base)
+	to := slices.Index(repo.Commits, head)
+	if !ok || from < 0 || to < 0 {
+		writeError(w, http.StatusNotFound, "commit does not exist")
+		return
+	}
+	commits := []map[string]any{}
+	for i := from + 1; i <= to; i++ {
+		commits = append(commits, map[string]any{"sha": repo.Commits[i]})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"total_commits": len(commits), "commits": commits})
+}
+
+func (s *Server) findPull(w http.ResponseWriter, r *http.Request, repo *Repo) *Pull {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err == nil {
+		for i := range repo.Pulls {
+			if repo.Pulls[i].Number == number {
+				return &repo.Pulls[i]
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "pull request does not exist")
+	return nil
+}
+
+func (s *Server) getPull(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	pull := s.findPull(w, r, repo)
+	if pull != nil {
+		writeJSON(w, http.StatusOK, s.pullJSON(repo, pull))
+	}
+}
+
+func (s *Server) getPullCommits(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	pull := s.findPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	page, err := strconv.Atoi(cmp.Or(r.URL.Query().Get("page"), "1"))
+	if err != nil || page < 1 {
+		writeError(w, http.StatusUnprocessableEntity, "invalid page")
+		return
+	}
+	limit, err := strconv.Atoi(cmp.Or(r.URL.Query().Get("limit"), "30"))
+	if err != nil || limit < 1 {
+		writeError(w, http.StatusUnprocessableEntity, "invalid limit")
+		return
+	}
+	start := min((page-1)*limit, len(pull.Commits))
+	end := min(start+limit, len(pull.Commits))
+	if end < len(pull.Commits) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, s.URL, next.RequestURI()))
+	}
+	commits := []map[string]any{}
+	for _, sha := range pull.Commits[start:end] {
+		commits = append(commits, map[string]any{"sha": sha})
+	}
+	writeJSON(w, http.StatusOK, commits)
+}
+
+func (s *Server) listReleases(w http.ResponseWriter, _ *http.Request, repo *Repo) {
+	releases := []map[string]any{}
+	for _, rel := range slices.Backward(repo.Releases) {
+		releases = append(releases, map[string]any{
+			"id":         rel.ID,
+			"tag_name":   rel.TagName,
+			"draft":      rel.Draft,
+			"prerelease": rel.Prerelease,
+			"html_url":   s.htmlURL(repo) + "/releases/tag/" + rel.TagName,
+		})
+	}
+	writeJSON(w, http.StatusOK, releases)
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req struct {
+		TagName    string `json:"tag_name"`
+		Name       string `json:"name"`
+		Body       string `json:"body"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.TagName == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid release")
+		return
+	}
+	s.lastID++
+	rel := &Release{
+		ID:         s.lastID,
+		TagName:    req.TagName,
+		Name:       req.Name,
+		Body:       req.Body,
+		Draft:      req.Draft,
+		Prerelease: req.Prerelease,
+		Assets:     map[string]string{},
+	}
+	repo.Releases = append(repo.Releases, rel)
+	writeJSON(w, http.StatusCreated, map[string]any{"id": rel.ID, "tag_name": rel.TagName})
+}
+
+func (s *Server) findRelease(w http.ResponseWriter, r *http.Request, repo *Repo) int {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err == nil {
+		for i, rel := range repo.Releases {
+			if rel.ID == id {
+				return i
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "release does not exist")
+	return -1
+}
+
+func (s *Server) editRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	idx := s.findRelease(w, r, repo)
+	if idx < 0 {
+		return
+	}
+	var req struct {
+		Draft *bool `json:"draft"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid release")
+		return
+	}
+	rel := repo.Releases[idx]
+	if req.Draft != nil {
+		rel.Draft = *req.Draft
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":       rel.ID,
+		"tag_name": rel.TagName,
+		"draft":    rel.Draft,
+		"html_url": s.htmlURL(repo) + "/releases/tag/" + rel.TagName,
+	})
+}
+
+func (s *Server) deleteRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	idx := s.findRelease(w, r, repo)
+	if idx < 0 {
+		return
+	}
+	repo.Releases = slices.Delete(repo.Releases, idx, idx+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	idx := s.findRelease(w, r, repo)
+	if idx < 0 {
+		return
+	}
+	file, header, err := r.FormFile("attachment")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := cmp.Or(r.URL.Query().Get("name"), header.Filename)
+	repo.Releases[idx].Assets[name] = string(content)
+	writeJSON(w, http.StatusCreated, map[string]any{"name": name, "size": len(content)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
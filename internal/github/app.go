@@ -0,0 +1,172 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	ratelimit "github.com/gofri/go-github-ratelimit/v2/github_ratelimit"
+	"github.com/google/go-github/v72/github"
+)
+
+// App is a GitHub App that a Client authenticates as.
+type App struct {
+	ID int64
+	// InstallationID is the app's installation on the repository. When it is 0, the installation is looked up from
+	// Owner and Repo.
+	InstallationID int64
+	Owner          string
+	Repo           string
+	// PrivateKey is the app's PEM encoded private key.
+	PrivateKey []byte
+}
+
+// tokenRefreshMargin is how long before an installation token expires that it is replaced. It leaves time for the
+// request that uses the token to finish.
+const tokenRefreshMargin = 5 * time.Minute
+
+// appTransport authenticates requests with an installation token for a GitHub App. Installation tokens last an hour,
+// so a new one is created whenever the current one is about to expire. Because that is checked on every request, long
+// runs such as uploading many release assets keep working.
+type appTransport struct {
+	base      http.RoundTripper
+	baseURL   string
+	userAgent string
+	app       App
+	key       *rsa.PrivateKey
+	now       func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewAppClient returns a Client that authenticates as the installation of app.
+func NewAppClient(baseUrl string, app *App, userAgent string) (*Client, error) {
+	key, err := parsePrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	auth := &appTransport{
+		base:      http.DefaultTransport,
+		baseURL:   baseUrl,
+		userAgent: userAgent,
+		app:       *app,
+		key:       key,
+		now:       time.Now,
+	}
+	httpClient := &http.Client{Transport: ratelimit.NewSecondaryLimiter(auth)}
+	githubClient, err := github.NewClient(httpClient).WithEnterpriseURLs(baseUrl, "")
+	if err != nil {
+		return nil, err
+	}
+	githubClient.UserAgent = userAgent
+	return &Client{client: githubClient, app: auth}, nil
+}
+
+// InstallationToken returns the current installation token of a client created with NewAppClient.
+func (g *Client) InstallationToken(ctx context.Context) (string, error) {
+	if g.app == nil {
+		return "", errors.New("the client doesn't authenticate as a GitHub App")
+	}
+	return g.app.installationToken(ctx)
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// installationToken returns the current installation token, creating a new one when there is none or it is about to
+// expire.
+func (t *appTransport) installationToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && t.now().Add(tokenRefreshMargin).Before(t.expires) {
+		return t.token, nil
+	}
+	jwt, err := t.jwt()
+	if err != nil {
+		return "", err
+	}
+	appClient, err := github.NewClient(&http.Client{Transport: t.base}).WithAuthToken(jwt).WithEnterpriseURLs(t.baseURL, "")
+	if err != nil {
+		return "", err
+	}
+	appClient.UserAgent = t.userAgent
+	if t.app.InstallationID == 0 {
+		installation, _, e := appClient.Apps.FindRepositoryInstallation(ctx, t.app.Owner, t.app.Repo)
+		if e != nil {
+			return "", fmt.Errorf("finding the GitHub App installation for %s/%s: %w", t.app.Owner, t.app.Repo, e)
+		}
+		t.app.InstallationID = installation.GetID()
+	}
+	token, _, err := appClient.Apps.CreateInstallationToken(ctx, t.app.InstallationID, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating a GitHub App installation token: %w", err)
+	}
+	t.token = token.GetToken()
+	t.expires = token.GetExpiresAt().Time
+	return t.token, nil
+}
+
+// jwt returns a JSON Web Token that authenticates as the app itself. It is only used to create installation tokens.
+// iat is backdated to allow for clock drift, and GitHub rejects an exp more than 10 minutes out.
+func (t *appTransport) jwt() (string, error) {
+	now := t.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": t.app.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey parses a PEM encoded RSA key. GitHub's keys are PKCS #1, but PKCS #8 is accepted too.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the GitHub App private key isn't PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the GitHub App private key isn't an RSA key")
+	}
+	return rsaKey, nil
+}
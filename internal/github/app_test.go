@@ -0,0 +1,97 @@
+package github
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
+)
+
+func TestNewAppClient(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	newServer := func(t *testing.T) (*githubtest.Server, *githubtest.Repo) {
+		t.Helper()
+		server := githubtest.NewServer("static-token")
+		t.Cleanup(server.Close)
+		server.App = &githubtest.App{ID: 12, InstallationID: 34, PublicKey: &key.PublicKey}
+		return server, server.AddRepo("orgname", "repo", "c0", "c1")
+	}
+
+	t.Run("finds the installation", func(t *testing.T) {
+		t.Parallel()
+		server, repo := newServer(t)
+		client, err := NewAppClient(server.APIURL, &App{ID: 12, Owner: "orgname", Repo: "repo", PrivateKey: privateKey}, "release-train/test")
+		require.NoError(t, err)
+		require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "main", "c0"))
+		require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "main", "c1"))
+		require.Equal(t, "c1", server.Ref(repo, "refs/heads/main"))
+		require.Equal(t, 1, server.InstallationTokens())
+
+		token, err := client.InstallationToken(t.Context())
+		require.NoError(t, err)
+		require.Regexp(t, `^ghs_`, token)
+	})
+
+	t.Run("replaces tokens that are about to expire", func(t *testing.T) {
+		t.Parallel()
+		server, _ := newServer(t)
+		server.App.TokenLifetime = tokenRefreshMargin - time.Minute
+		client, err := NewAppClient(server.APIURL, &App{ID: 12, InstallationID: 34, PrivateKey: privateKey}, "release-train/test")
+		require.NoError(t, err)
+		token, err := client.InstallationToken(t.Context())
+		require.NoError(t, err)
+		// UpdateBranch creates the branch after failing to update it, so that's two more requests
+		require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "main", "c0"))
+		require.Equal(t, 3, server.InstallationTokens())
+		refreshed, err := client.InstallationToken(t.Context())
+		require.NoError(t, err)
+		require.NotEqual(t, token, refreshed)
+	})
+
+	t.Run("installation ID", func(t *testing.T) {
+		t.Parallel()
+		server, _ := newServer(t)
+		client, err := NewAppClient(server.APIURL, &App{ID: 12, InstallationID: 34, PrivateKey: privateKey}, "release-train/test")
+		require.NoError(t, err)
+		require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "main", "c0"))
+
+		client, err = NewAppClient(server.APIURL, &App{ID: 12, InstallationID: 35, PrivateKey: privateKey}, "release-train/test")
+		require.NoError(t, err)
+		err = client.UpdateBranch(t.Context(), "orgname", "repo", "main", "c0")
+		require.ErrorContains(t, err, "creating a GitHub App installation token")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		t.Parallel()
+		server, _ := newServer(t)
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(otherKey)
+		require.NoError(t, err)
+		client, err := NewAppClient(server.APIURL, &App{
+			ID:         12,
+			Owner:      "orgname",
+			Repo:       "repo",
+			PrivateKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		}, "release-train/test")
+		require.NoError(t, err)
+		_, err = client.InstallationToken(t.Context())
+		require.ErrorContains(t, err, "A JSON web token could not be decoded")
+		require.Zero(t, server.InstallationTokens())
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewAppClient("https://api.github.com/", &App{ID: 12, PrivateKey: []byte("nope")}, "release-train/test")
+		require.EqualError(t, err, "the GitHub App private key isn't PEM encoded")
+	})
+}
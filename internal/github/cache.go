@@ -0,0 +1,107 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// commitCache stores the merged pulls for commits on disk so later runs don't need to ask the API again. Entries are
+// keyed by repository and commit sha.
+type commitCache struct {
+	dir string
+}
+
+// commitCacheEntry is the cached result of looking up a commit's pulls.
+type commitCacheEntry struct {
+	// Final is true when the commit has pulls and none of them are open, so no pull will be merged with it later.
+	// Other entries are revalidated with ETag.
+	Final bool       `json:"final"`
+	ETag  string     `json:"etag,omitempty"`
+	Pulls []BasePull `json:"pulls"`
+}
+
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+func (c *commitCache) path(owner, repo, sha string) (string, error) {
+	for _, name := range []string{owner, repo} {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", fmt.Errorf("invalid repository name for cache: %s/%s", owner, repo)
+		}
+	}
+	if !shaPattern.MatchString(sha) {
+		return "", fmt.Errorf("invalid commit sha for cache: %s", sha)
+	}
+	// GitHub repository names are case-insensitive
+	return filepath.Join(c.dir, strings.ToLower(owner), strings.ToLower(repo), "commits", sha+".json"), nil
+}
+
+// get returns the cached entry for a commit or nil when there is none. Unreadable entries are treated as missing.
+func (c *commitCache) get(owner, repo, sha string) *commitCacheEntry {
+	if c == nil {
+		return nil
+	}
+	filename, err := c.path(owner, repo, sha)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	var entry commitCacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// store is put for callers that have the pulls already. The cache is optional, so errors are only logged.
+func (c *commitCache) store(owner, repo, sha string, entry *commitCacheEntry) {
+	err := c.put(owner, repo, sha, entry)
+	if err != nil {
+		slog.Warn("failed to cache the pulls for a commit", slog.String("sha", sha), slog.Any("error", err))
+	}
+}
+
+func (c *commitCache) put(owner, repo, sha string, entry *commitCacheEntry) error {
+	if c == nil {
+		return nil
+	}
+	filename, err := c.path(owner, repo, sha)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0o750)
+	if err != nil {
+		return err
+	}
+	// write to a temp file first so concurrent runs never read a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		removeErr := os.Remove(tmp.Name())
+		if errors.Is(removeErr, fs.ErrNotExist) {
+			removeErr = nil
+		}
+		return errors.Join(err, removeErr)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithCache(t *testing.T) {
+	t.Parallel()
+	const (
+		mergedSha = "1111111111111111111111111111111111111111"
+		openSha   = "2222222222222222222222222222222222222222"
+		bareSha   = "3333333333333333333333333333333333333333"
+	)
+
+	newClient := func(t *testing.T, handler http.Handler) *Client {
+		t.Helper()
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		client, err := NewClient(server.URL+"/api/v3/", "token", "release-train/test")
+		require.NoError(t, err)
+		return client.WithCache(t.TempDir())
+	}
+
+	t.Run("REST", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		requests := map[string]int{}
+		notModified := map[string]int{}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/commits/{sha}/pulls", func(w http.ResponseWriter, r *http.Request) {
+			sha := r.PathValue("sha")
+			mu.Lock()
+			defer mu.Unlock()
+			requests[sha]++
+			etag := `"` + sha + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				notModified[sha]++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			switch sha {
+			case mergedSha:
+				writeJSON(t, w, []any{map[string]any{
+					"number":           1,
+					"state":            "closed",
+					"merge_commit_sha": "m1",
+					"merged_at":        "2024-01-01T00:00:00Z",
+					"labels":           []any{map[string]any{"name": "semver:minor"}},
+				}})
+			case openSha:
+				writeJSON(t, w, []any{
+					map[string]any{"number": 2, "state": "closed", "merge_commit_sha": "m2", "merged_at": "2024-01-01T00:00:00Z"},
+					map[string]any{"number": 3, "state": "open", "merge_commit_sha": "test-merge"},
+				})
+			default:
+				writeJSON(t, w, []any{})
+			}
+		})
+		client := newClient(t, mux)
+		for range 3 {
+			got, err := client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", mergedSha)
+			require.NoError(t, err)
+			require.Equal(t, []BasePull{{Number: 1, MergeCommitSha: "m1", Labels: []string{"semver:minor"}}}, got)
+
+			got, err = client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", openSha)
+			require.NoError(t, err)
+			require.Equal(t, []BasePull{
+				{Number: 2, MergeCommitSha: "m2", Labels: []string{}},
+				{Number: 3, MergeCommitSha: "test-merge", Labels: []string{}},
+			}, got)
+
+			got, err = client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", bareSha)
+			require.NoError(t, err)
+			require.Empty(t, got)
+		}
+		// repository names are case-insensitive, so this is served from the same entry
+		_, err := client.ListMergedPullsForCommit(t.Context(), "OrgName", "Repo", mergedSha)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		// only commits that have open pulls or no pulls are revalidated
+		require.Equal(t, map[string]int{mergedSha: 1, openSha: 3, bareSha: 3}, requests)
+		require.Equal(t, map[string]int{openSha: 2, bareSha: 2}, notModified)
+	})
+
+	t.Run("relabeled", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		requests := 0
+		label, state := "documentation", "open"
+		client := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests++
+			etag := `"` + label + state + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			writeJSON(t, w, []any{
+				map[string]any{
+					"number":           1,
+					"state":            "closed",
+					"merge_commit_sha": "m1",
+					"merged_at":        "2024-01-01T00:00:00Z",
+					"labels":           []any{map[string]any{"name": label}},
+				},
+				map[string]any{"number": 2, "state": state, "merge_commit_sha": "test-merge"},
+			})
+		}))
+		got, err := client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", mergedSha)
+		require.NoError(t, err)
+		require.Equal(t, []string{"documentation"}, got[0].Labels)
+		// labels added while another pull is open are seen
+		mu.Lock()
+		label, state = "semver:patch", "closed"
+		mu.Unlock()
+		got, err = client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", mergedSha)
+		require.NoError(t, err)
+		require.Equal(t, []string{"semver:patch"}, got[0].Labels)
+		// now that no pull is open the commit is served from the cache
+		_, err = client.ListMergedPullsForCommit(t.Context(), "orgname", "repo", mergedSha)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, 2, requests)
+	})
+
+	t.Run("unwritable", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(t, w, []any{map[string]any{"number": 1, "state": "closed", "merge_commit_sha": "m1"}})
+		}))
+		t.Cleanup(server.Close)
+		client, err := NewClient(server.URL+"/api/v3/", "token", "release-train/test")
+		require.NoError(t, err)
+		// the cache directory can't be created under a file
+		dir := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(dir, nil, 0o600))
+		got, err := client.WithCache(dir).ListMergedPullsForCommit(t.Context(), "orgname", "repo", mergedSha)
+		require.NoError(t, err)
+		require.Equal(t, []BasePull{{Number: 1, MergeCommitSha: "m1", Labels: []string{}}}, got)
+	})
+
+	t.Run("GraphQL", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		var batches [][]string
+		var revalidated []string
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Variables map[string]any `json:"variables"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var batch []string
+			repository := map[string]any{}
+			for i := 0; req.Variables[fmt.Sprintf("c%d", i)] != nil; i++ {
+				alias := fmt.Sprintf("c%d", i)
+				batch = append(batch, req.Variables[alias].(string))
+				state := "MERGED"
+				if req.Variables[alias] == openSha {
+					state = "OPEN"
+				}
+				repository[alias] = map[string]any{"associatedPullRequests": map[string]any{
+					"nodes": []any{map[string]any{
+						"number":      1,
+						"state":       state,
+						"merged":      state == "MERGED",
+						"mergeCommit": map[string]any{"oid": "m1"},
+					}},
+				}}
+			}
+			mu.Lock()
+			batches = append(batches, batch)
+			mu.Unlock()
+			writeJSON(t, w, map[string]any{"data": map[string]any{"repository": repository}})
+		})
+		mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/commits/{sha}/pulls", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			revalidated = append(revalidated, r.PathValue("sha"))
+			mu.Unlock()
+			writeJSON(t, w, []any{map[string]any{"number": 1, "state": "open"}})
+		})
+		client := newClient(t, mux)
+		shas := []string{mergedSha, openSha}
+		for range 2 {
+			got, err := client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", shas)
+			require.NoError(t, err)
+			require.Equal(t, []BasePull{{Number: 1, MergeCommitSha: "m1", Labels: []string{}}}, got[mergedSha])
+			require.Empty(t, got[openSha])
+			shas = append(shas, bareSha)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// the merged commit is cached and the open one is revalidated with the REST API
+		require.Equal(t, [][]string{{mergedSha, openSha}, {bareSha}}, batches)
+		require.Equal(t, []string{openSha}, revalidated)
+	})
+}
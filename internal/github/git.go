@@ -0,0 +1,174 @@
+package github
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// FileChange is a file written or deleted by a commit. Path is relative to the repository root.
+type FileChange struct {
+	Path    string
+	Content []byte
+	// Mode is the git file mode such as 100755 or 120000 for symlinks. Regular files are 100644.
+	Mode   string
+	Delete bool
+}
+
+// CommitAuthor is the author of a commit.
+type CommitAuthor struct {
+	Name  string
+	Email string
+	Date  time.Time
+}
+
+// NewCommit is a commit to create on top of Parent.
+type NewCommit struct {
+	Parent  string
+	Message string
+	Files   []FileChange
+	// Author defaults to the token's user.
+	Author *CommitAuthor
+}
+
+// CreateCommit creates a commit with the Git Data API and returns its sha. The commit isn't on any branch until a ref
+// points to it. GitHub is the committer, so it signs the commit.
+func (g *Client) CreateCommit(ctx context.Context, owner, repo string, commit *NewCommit) (string, error) {
+	parent, _, err := g.client.Git.GetCommit(ctx, owner, repo, commit.Parent)
+	if err != nil {
+		return "", err
+	}
+	entries := make([]*github.TreeEntry, len(commit.Files))
+	for i, file := range commit.Files {
+		mode := cmp.Or(file.Mode, "100644")
+		if file.Delete {
+			// a nil sha removes the path from the tree
+			entries[i] = &github.TreeEntry{Path: github.Ptr(file.Path), Mode: github.Ptr(mode), Type: github.Ptr("blob")}
+			continue
+		}
+		// blobs are base64 encoded so that files don't need to be valid utf-8
+		blob, _, e := g.client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+			Content:  github.Ptr(base64.StdEncoding.EncodeToString(file.Content)),
+			Encoding: github.Ptr("base64"),
+		})
+		if e != nil {
+			return "", e
+		}
+		entries[i] = &github.TreeEntry{
+			Path: github.Ptr(file.Path),
+			Mode: github.Ptr(mode),
+			Type: github.Ptr("blob"),
+			SHA:  blob.SHA,
+		}
+	}
+	tree, _, err := g.client.Git.CreateTree(ctx, owner, repo, parent.GetTree().GetSHA(), entries)
+	if err != nil {
+		return "", err
+	}
+	newCommit := &github.Commit{
+		Message: github.Ptr(commit.Message),
+		Tree:    tree,
+		Parents: []*github.Commit{{SHA: github.Ptr(commit.Parent)}},
+	}
+	if commit.Author != nil {
+		newCommit.Author = &github.CommitAuthor{
+			Name:  github.Ptr(commit.Author.Name),
+			Email: github.Ptr(commit.Author.Email),
+			Date:  &github.Timestamp{Time: commit.Author.Date},
+		}
+	}
+	created, _, err := g.client.Git.CreateCommit(ctx, owner, repo, newCommit, nil)
+	if err != nil {
+		return "", err
+	}
+	return created.GetSHA(), nil
+}
+
+// NewTag is a tag to create on the commit Sha. Tags with a Message are annotated.
+type NewTag struct {
+	Name    string
+	Sha     string
+	Message string
+}
+
+// CreateTag creates a tag with the Git Data API. It fails when the tag already exists.
+func (g *Client) CreateTag(ctx context.Context, owner, repo string, tag *NewTag) error {
+	sha := tag.Sha
+	if tag.Message != "" {
+		created, _, err := g.client.Git.CreateTag(ctx, owner, repo, &github.Tag{
+			Tag:     github.Ptr(tag.Name),
+			Message: github.Ptr(tag.Message),
+			Object:  &github.GitObject{SHA: github.Ptr(tag.Sha), Type: github.Ptr("commit")},
+		})
+		if err != nil {
+			return err
+		}
+		sha = created.GetSHA()
+	}
+	_, _, err := g.client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.Ptr("refs/tags/" + tag.Name),
+		Object: &github.GitObject{SHA: github.Ptr(sha)},
+	})
+	return err
+}
+
+// DeleteTag deletes a tag with the Git Data API.
+func (g *Client) DeleteTag(ctx context.Context, owner, repo, tag string) error {
+	_, err := g.client.Git.DeleteRef(ctx, owner, repo, "refs/tags/"+tag)
+	return err
+}
+
+// FastForwardBranch points the existing branch at sha. It fails when sha doesn't descend from the branch's commit.
+func (g *Client) FastForwardBranch(ctx context.Context, owner, repo, branch, sha string) error {
+	_, _, err := g.client.Git.UpdateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.Ptr("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.Ptr(sha)},
+	}, false)
+	return err
+}
+
+// UpdateBranch points branch at sha, creating the branch when it doesn't exist. The update is forced, so the branch's
+// previous commits are discarded.
+func (g *Client) UpdateBranch(ctx context.Context, owner, repo, branch, sha string) error {
+	return g.forceRef(ctx, owner, repo, "refs/heads/"+branch, sha)
+}
+
+// MoveTag points tag at sha, creating the tag when it doesn't exist.
+func (g *Client) MoveTag(ctx context.Context, owner, repo, tag, sha string) error {
+	return g.forceRef(ctx, owner, repo, "refs/tags/"+tag, sha)
+}
+
+// forceRef points the full ref name at sha with a forced update, creating the ref when it doesn't exist.
+func (g *Client) forceRef(ctx context.Context, owner, repo, name, sha string) error {
+	ref := &github.Reference{
+		Ref:    github.Ptr(name),
+		Object: &github.GitObject{SHA: github.Ptr(sha)},
+	}
+	_, _, err := g.client.Git.UpdateRef(ctx, owner, repo, ref, true)
+	if !isRefMissing(err) {
+		return err
+	}
+	_, _, err = g.client.Git.CreateRef(ctx, owner, repo, ref)
+	return err
+}
+
+// isRefMissing returns true when err is GitHub's response to updating a ref that doesn't exist. GitHub reports it as
+// 422 "Reference does not exist", though older servers use 404.
+func isRefMissing(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return false
+	}
+	switch errResp.Response.StatusCode {
+	case http.StatusNotFound:
+		return true
+	case http.StatusUnprocessableEntity:
+		return errResp.Message == "Reference does not exist"
+	}
+	return false
+}
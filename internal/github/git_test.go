@@ -0,0 +1,144 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
+)
+
+func newFakeClient(t *testing.T) (*Client, *githubtest.Server, *githubtest.Repo) {
+	t.Helper()
+	server := githubtest.NewServer("token")
+	t.Cleanup(server.Close)
+	repo := server.AddRepo("orgname", "repo", "c0", "c1")
+	client, err := NewClient(server.APIURL, "token", "release-train/test")
+	require.NoError(t, err)
+	return client, server, repo
+}
+
+func TestClient_CreateCommit(t *testing.T) {
+	t.Parallel()
+	client, server, _ := newFakeClient(t)
+	first, err := client.CreateCommit(t.Context(), "orgname", "repo", &NewCommit{
+		Parent:  "c1",
+		Message: "first",
+		Files:   []FileChange{{Path: "a.txt", Content: []byte("a")}, {Path: "dir/b.bin", Content: []byte{0xff, 0}}},
+	})
+	require.NoError(t, err)
+	second, err := client.CreateCommit(t.Context(), "orgname", "repo", &NewCommit{
+		Parent:  first,
+		Message: "second",
+		Files:   []FileChange{{Path: "a.txt", Content: []byte("aa")}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &githubtest.Commit{
+		Sha:     second,
+		Message: "second",
+		Parents: []string{first},
+		Files:   map[string]string{"a.txt": "aa", "dir/b.bin": "\xff\x00"},
+	}, server.Commit(second))
+
+	third, err := client.CreateCommit(t.Context(), "orgname", "repo", &NewCommit{
+		Parent:  second,
+		Message: "third",
+		Files:   []FileChange{{Path: "a.txt", Delete: true}, {Path: "run.sh", Content: []byte("true"), Mode: "100755"}},
+		Author:  &CommitAuthor{Name: "tester", Email: "tester@example.com", Date: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &githubtest.Commit{
+		Sha:     third,
+		Message: "third",
+		Parents: []string{second},
+		Files:   map[string]string{"dir/b.bin": "\xff\x00", "run.sh": "true"},
+		Author:  "tester <tester@example.com>",
+	}, server.Commit(third))
+
+	_, err = client.CreateCommit(t.Context(), "orgname", "repo", &NewCommit{Parent: "missing", Message: "x"})
+	require.Error(t, err)
+}
+
+func TestClient_CreateTag(t *testing.T) {
+	t.Parallel()
+	client, server, repo := newFakeClient(t)
+	require.NoError(t, client.CreateTag(t.Context(), "orgname", "repo", &NewTag{Name: "v1.0.0", Sha: "c0"}))
+	require.Equal(t, "c0", server.Ref(repo, "refs/tags/v1.0.0"))
+
+	require.NoError(t, client.CreateTag(t.Context(), "orgname", "repo", &NewTag{Name: "v1.1.0", Sha: "c1", Message: "Release v1.1.0"}))
+	tag := server.Tag(server.Ref(repo, "refs/tags/v1.1.0"))
+	require.NotNil(t, tag)
+	require.Equal(t, "v1.1.0", tag.Name)
+	require.Equal(t, "Release v1.1.0", tag.Message)
+	require.Equal(t, "c1", tag.Object)
+
+	require.Error(t, client.CreateTag(t.Context(), "orgname", "repo", &NewTag{Name: "v1.1.0", Sha: "c1"}))
+
+	require.NoError(t, client.DeleteTag(t.Context(), "orgname", "repo", "v1.1.0"))
+	require.Empty(t, server.Ref(repo, "refs/tags/v1.1.0"))
+	require.Error(t, client.DeleteTag(t.Context(), "orgname", "repo", "v1.1.0"))
+}
+
+func TestClient_MoveTag(t *testing.T) {
+	t.Parallel()
+	client, server, repo := newFakeClient(t)
+	require.NoError(t, client.MoveTag(t.Context(), "orgname", "repo", "v1", "c1"))
+	require.Equal(t, "c1", server.Ref(repo, "refs/tags/v1"))
+	require.NoError(t, client.MoveTag(t.Context(), "orgname", "repo", "v1", "c0"))
+	require.Equal(t, "c0", server.Ref(repo, "refs/tags/v1"))
+}
+
+func TestClient_FastForwardBranch(t *testing.T) {
+	t.Parallel()
+	client, server, repo := newFakeClient(t)
+	require.Error(t, client.FastForwardBranch(t.Context(), "orgname", "repo", "main", "c0"))
+	require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "main", "c0"))
+	require.NoError(t, client.FastForwardBranch(t.Context(), "orgname", "repo", "main", "c1"))
+	require.Equal(t, "c1", server.Ref(repo, "refs/heads/main"))
+	require.Error(t, client.FastForwardBranch(t.Context(), "orgname", "repo", "main", "c0"))
+	require.Equal(t, "c1", server.Ref(repo, "refs/heads/main"))
+}
+
+func TestClient_UpdateBranch(t *testing.T) {
+	t.Parallel()
+	client, server, repo := newFakeClient(t)
+	require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "release", "c1"))
+	require.Equal(t, "c1", server.Ref(repo, "refs/heads/release"))
+	// not a fast-forward, so this only works because the update is forced
+	require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "release", "c0"))
+	require.Equal(t, "c0", server.Ref(repo, "refs/heads/release"))
+}
+
+func TestClient_pullRequests(t *testing.T) {
+	t.Parallel()
+	client, server, repo := newFakeClient(t)
+	require.NoError(t, client.UpdateBranch(t.Context(), "orgname", "repo", "release", "c1"))
+	server.AddPull(repo, githubtest.Pull{Number: 4, Head: "release", Base: "other", Open: true})
+	server.AddPull(repo, githubtest.Pull{Number: 5, Head: "release", Base: "main", Merged: true})
+
+	got, err := client.FindOpenPullRequest(t.Context(), "orgname", "repo", "release", "main")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	created, err := client.CreatePullRequest(t.Context(), "orgname", "repo", &NewPull{
+		Title:  "Release v1.0.0",
+		Body:   "body",
+		Head:   "release",
+		Base:   "main",
+		Labels: []string{"semver:none"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 6, created.Number)
+	require.Equal(t, []string{"semver:none"}, created.Labels)
+
+	require.NoError(t, client.UpdatePullRequest(t.Context(), "orgname", "repo", 6, "Release v1.1.0", "new body"))
+	got, err = client.FindOpenPullRequest(t.Context(), "orgname", "repo", "release", "main")
+	require.NoError(t, err)
+	require.Equal(t, &BasePull{
+		Number: 6,
+		Labels: []string{"semver:none"},
+		Title:  "Release v1.1.0",
+		URL:    server.URL + "/orgname/repo/pull/6",
+	}, got)
+	require.Equal(t, "new body", server.Pulls(repo)[2].Body)
+}
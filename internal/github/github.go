@@ -2,6 +2,7 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -19,11 +20,20 @@ type BasePull struct {
 	Number         int
 	MergeCommitSha string
 	Labels         []string
+	Title          string
+	Author         string
+	URL            string
+	BaseSha        string
 }
 
 type RepoRelease struct {
 	ID        int64
 	UploadURL string
+	URL       string
+	// Tag, Draft and Prerelease are only set by ListReleases.
+	Tag        string
+	Draft      bool
+	Prerelease bool
 }
 
 type CommitComparison struct {
@@ -34,12 +44,19 @@ type CommitComparison struct {
 
 type Client struct {
 	client *github.Client
+	cache  *commitCache
+	app    *appTransport
+	// anonymous is true when requests aren't authenticated, which the GraphQL API doesn't allow.
+	anonymous bool
 }
 
 func NewClient(baseUrl, token, userAgent string) (*Client, error) {
 	transport := ratelimit.NewSecondaryLimiter(http.DefaultTransport)
 	httpClient := &http.Client{Transport: transport}
-	githubClient := github.NewClient(httpClient).WithAuthToken(token)
+	githubClient := github.NewClient(httpClient)
+	if token != "" {
+		githubClient = githubClient.WithAuthToken(token)
+	}
 
 	// no need for uploadURL because if we upload release artifacts we will use release.UploadURL
 	githubClient, err := githubClient.WithEnterpriseURLs(baseUrl, "")
@@ -47,7 +64,16 @@ func NewClient(baseUrl, token, userAgent string) (*Client, error) {
 		return nil, err
 	}
 	githubClient.UserAgent = userAgent
-	return &Client{client: githubClient}, nil
+	return &Client{client: githubClient, anonymous: token == ""}, nil
+}
+
+// WithCache returns a client that caches the pulls for commits in dir. Commits whose pulls are all merged or closed are
+// served from the cache without asking the API. Others are revalidated with conditional requests, which don't count
+// against the rate limit when nothing changed.
+func (g *Client) WithCache(dir string) *Client {
+	c := *g
+	c.cache = &commitCache{dir: dir}
+	return &c
 }
 
 // UploadAsset is largely copied from github.Client.UploadReleaseAsset. It is modified to use uploadURL instead of
@@ -93,15 +119,42 @@ func (g *Client) UploadAsset(ctx context.Context, uploadURL, filename string) er
 }
 
 func (g *Client) ListMergedPullsForCommit(ctx context.Context, owner, repo, sha string) ([]BasePull, error) {
-	var result []BasePull
+	cached := g.cache.get(owner, repo, sha)
+	if cached != nil && cached.Final {
+		return cached.Pulls, nil
+	}
+	entry := commitCacheEntry{Final: true}
 	const pageSize = 100
 	opts := &github.ListOptions{PerPage: pageSize}
 	for {
-		apiPulls, resp, err := g.client.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, sha, opts)
+		u := fmt.Sprintf("repos/%v/%v/commits/%v/pulls?per_page=%d", owner, repo, sha, opts.PerPage)
+		if opts.Page != 0 {
+			u += fmt.Sprintf("&page=%d", opts.Page)
+		}
+		req, err := g.client.NewRequest(http.MethodGet, u, nil)
 		if err != nil {
 			return nil, err
 		}
+		req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
+		// only the first page is revalidated. A commit with more pulls than that is never cached with an ETag.
+		if opts.Page == 0 && cached != nil && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		var apiPulls []*github.PullRequest
+		resp, err := g.client.Do(ctx, req, &apiPulls)
+		if isNotModified(err) {
+			return cached.Pulls, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts.Page == 0 && resp.NextPage == 0 {
+			entry.ETag = resp.Header.Get("ETag")
+		}
 		for _, apiPull := range apiPulls {
+			if apiPull.GetState() == "open" {
+				entry.Final = false
+			}
 			mergeCommitSHA := apiPull.GetMergeCommitSHA()
 			// only include merged PRs
 			if mergeCommitSHA == "" {
@@ -111,18 +164,29 @@ func (g *Client) ListMergedPullsForCommit(ctx context.Context, owner, repo, sha
 				Number:         apiPull.GetNumber(),
 				Labels:         make([]string, len(apiPull.Labels)),
 				MergeCommitSha: mergeCommitSHA,
+				Title:          apiPull.GetTitle(),
+				Author:         apiPull.GetUser().GetLogin(),
+				URL:            apiPull.GetHTMLURL(),
 			}
 			for i, label := range apiPull.Labels {
 				resultPull.Labels[i] = label.GetName()
 			}
-			result = append(result, resultPull)
+			entry.Pulls = append(entry.Pulls, resultPull)
 		}
 		if resp.NextPage == 0 {
 			break
 		}
 		opts.Page = resp.NextPage
 	}
-	return result, nil
+	// a commit without pulls may still get one
+	entry.Final = entry.Final && len(entry.Pulls) > 0
+	g.cache.store(owner, repo, sha, &entry)
+	return entry.Pulls, nil
+}
+
+func isNotModified(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response.StatusCode == http.StatusNotModified
 }
 
 // CompareCommits returns a commit comparison that includes up to count commits. If count is -1, all commits are
@@ -203,16 +267,51 @@ func (g *Client) DeleteRelease(ctx context.Context, owner, repo string, id int64
 	return err
 }
 
-func (g *Client) PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) error {
+func (g *Client) PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) (*RepoRelease, error) {
 	release := github.RepositoryRelease{Draft: github.Ptr(false)}
 	if !slices.Contains([]string{"", "legacy", "true", "false"}, makeLatest) {
-		return fmt.Errorf("invalid makeLatest value: %s", makeLatest)
+		return nil, fmt.Errorf("invalid makeLatest value: %s", makeLatest)
 	}
 	if makeLatest != "" {
 		release.MakeLatest = &makeLatest
 	}
-	_, _, err := g.client.Repositories.EditRelease(ctx, owner, repo, id, &release)
-	return err
+	rel, _, err := g.client.Repositories.EditRelease(ctx, owner, repo, id, &release)
+	if err != nil {
+		return nil, err
+	}
+	return &RepoRelease{
+		ID:        rel.GetID(),
+		UploadURL: rel.GetUploadURL(),
+		URL:       rel.GetHTMLURL(),
+	}, nil
+}
+
+// ListReleases returns the repository's releases, including drafts when the token can see them.
+func (g *Client) ListReleases(ctx context.Context, owner, repo string) ([]RepoRelease, error) {
+	var releases []RepoRelease
+	const pageSize = 100
+	opts := &github.ListOptions{PerPage: pageSize}
+	for {
+		apiReleases, resp, err := g.client.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range apiReleases {
+			releases = append(releases, RepoRelease{
+				ID:         rel.GetID(),
+				UploadURL:  rel.GetUploadURL(),
+				URL:        rel.GetHTMLURL(),
+				Tag:        rel.GetTagName(),
+				Draft:      rel.GetDraft(),
+				Prerelease: rel.GetPrerelease(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return releases, nil
 }
 
 func (g *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*BasePull, error) {
@@ -221,8 +320,12 @@ func (g *Client) GetPullRequest(ctx context.Context, owner, repo string, number
 		return nil, err
 	}
 	pull := BasePull{
-		Number: p.GetNumber(),
-		Labels: make([]string, len(p.Labels)),
+		Number:  p.GetNumber(),
+		Labels:  make([]string, len(p.Labels)),
+		Title:   p.GetTitle(),
+		Author:  p.GetUser().GetLogin(),
+		URL:     p.GetHTMLURL(),
+		BaseSha: p.GetBase().GetSHA(),
 	}
 	for i, label := range p.Labels {
 		pull.Labels[i] = label.GetName()
@@ -249,3 +352,73 @@ func (g *Client) GetPullRequestCommits(ctx context.Context, owner, repo string,
 	}
 	return commitShas, nil
 }
+
+// NewPull is a pull request to open. Head and Base are branch names in the same repository.
+type NewPull struct {
+	Title  string
+	Body   string
+	Head   string
+	Base   string
+	Labels []string
+}
+
+// FindOpenPullRequest returns the open pull request from head into base or nil when there is none.
+func (g *Client) FindOpenPullRequest(ctx context.Context, owner, repo, head, base string) (*BasePull, error) {
+	pulls, _, err := g.client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  owner + ":" + head,
+		Base:  base,
+	})
+	if err != nil || len(pulls) == 0 {
+		return nil, err
+	}
+	pull := basePull(pulls[0])
+	return &pull, nil
+}
+
+// CreatePullRequest opens a pull request and adds its labels.
+func (g *Client) CreatePullRequest(ctx context.Context, owner, repo string, newPull *NewPull) (*BasePull, error) {
+	p, _, err := g.client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.Ptr(newPull.Title),
+		Body:  github.Ptr(newPull.Body),
+		Head:  github.Ptr(newPull.Head),
+		Base:  github.Ptr(newPull.Base),
+	})
+	if err != nil {
+		return nil, err
+	}
+	pull := basePull(p)
+	if len(newPull.Labels) == 0 {
+		return &pull, nil
+	}
+	_, _, err = g.client.Issues.AddLabelsToIssue(ctx, owner, repo, pull.Number, newPull.Labels)
+	if err != nil {
+		return nil, err
+	}
+	pull.Labels = append(pull.Labels, newPull.Labels...)
+	return &pull, nil
+}
+
+// UpdatePullRequest sets the title and body of a pull request.
+func (g *Client) UpdatePullRequest(ctx context.Context, owner, repo string, number int, title, body string) error {
+	_, _, err := g.client.PullRequests.Edit(ctx, owner, repo, number, &github.PullRequest{
+		Title: github.Ptr(title),
+		Body:  github.Ptr(body),
+	})
+	return err
+}
+
+func basePull(p *github.PullRequest) BasePull {
+	pull := BasePull{
+		Number:         p.GetNumber(),
+		MergeCommitSha: p.GetMergeCommitSHA(),
+		Labels:         make([]string, len(p.Labels)),
+		Title:          p.GetTitle(),
+		Author:         p.GetUser().GetLogin(),
+		URL:            p.GetHTMLURL(),
+	}
+	for i, label := range p.Labels {
+		pull.Labels[i] = label.GetName()
+	}
+	return pull
+}
@@ -0,0 +1,953 @@
+// Package githubtest provides an in-process fake GitHub server for tests. It implements the REST endpoints used by
+// github.Client. Its GraphQL endpoint only rejects requests without a token like GitHub does and is otherwise missing,
+// so batch lookups fall back to REST like they do on old GitHub Enterprise Server versions.
+package githubtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // git object ids are sha1
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake GitHub server. APIURL is the URL to pass to github.NewClient.
+type Server struct {
+	*httptest.Server
+	APIURL string
+	// Token is the token required in requests. Any request is accepted when it is empty.
+	Token string
+	// App makes the server act as a GitHub App that is installed on every repository. Installation tokens it creates
+	// are accepted along with Token.
+	App *App
+
+	mu      sync.Mutex
+	repos   map[string]*Repo
+	lastID  int64
+	blobs   map[string]string
+	trees   map[string]map[string]string
+	commits map[string]*Commit
+	tags    map[string]*Tag
+	// installationTokens maps installation tokens to their expiration.
+	installationTokens map[string]time.Time
+}
+
+// App is a GitHub App. JWTs must be signed by the app's private key.
+type App struct {
+	ID             int64
+	InstallationID int64
+	PublicKey      *rsa.PublicKey
+	// TokenLifetime is how long installation tokens last. Defaults to an hour like GitHub.
+	TokenLifetime time.Duration
+}
+
+// Repo is a repository on the fake server.
+type Repo struct {
+	Owner string
+	Name  string
+	// Commits is the repository's history, oldest first. Comparisons use positions in it. These commits have empty
+	// trees as far as the Git Data API is concerned.
+	Commits []string
+	Pulls   []*Pull
+	// Refs maps full ref names such as refs/heads/main to shas. Comparisons resolve branch and tag names with it.
+	Refs     map[string]string
+	Releases []*Release
+}
+
+// Pull is a pull request.
+type Pull struct {
+	Number         int
+	Title          string
+	Body           string
+	Author         string
+	Labels         []string
+	Merged         bool
+	MergeCommitSha string
+	Commits        []string
+	// Head and Base are branch names. Open is true for pull requests that are neither merged nor closed.
+	Head string
+	Base string
+	Open bool
+}
+
+// Release is a release and the assets uploaded to it.
+type Release struct {
+	ID         int64
+	TagName    string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	MakeLatest string
+	Assets     map[string]string
+}
+
+// Commit is a commit created with the Git Data API.
+type Commit struct {
+	Sha     string
+	Message string
+	Parents []string
+	// Files maps paths to content.
+	Files map[string]string
+	// Author is "name <email>" or empty when the request didn't set one.
+	Author string
+}
+
+// Tag is a tag object created with the Git Data API.
+type Tag struct {
+	Sha     string
+	Name    string
+	Message string
+	// Object is the sha of the tagged commit.
+	Object string
+}
+
+// NewServer starts a fake server. Close it when done.
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:   token,
+		repos:   map[string]*Repo{},
+		blobs:   map[string]string{},
+		trees:   map[string]map[string]string{},
+		commits: map[string]*Commit{},
+		tags:    map[string]*Tag{},
+
+		installationTokens: map[string]time.Time{},
+	}
+	s.Server = httptest.NewServer(s.handler())
+	s.APIURL = s.URL + "/api/v3/"
+	return s
+}
+
+// AddRepo adds a repository with a linear history.
+func (s *Server) AddRepo(owner, name string, commits ...string) *Repo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo := &Repo{Owner: owner, Name: name, Commits: commits, Refs: map[string]string{}}
+	s.repos[owner+"/"+name] = repo
+	return repo
+}
+
+// AddPull adds a pull request to a repository.
+func (s *Server) AddPull(repo *Repo, pull Pull) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo.Pulls = append(repo.Pulls, &pull)
+}
+
+// Pulls returns a copy of a repository's pull requests.
+func (s *Server) Pulls(repo *Repo) []Pull {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Pull, len(repo.Pulls))
+	for i, pull := range repo.Pulls {
+		result[i] = *pull
+		result[i].Labels = slices.Clone(pull.Labels)
+	}
+	return result
+}
+
+// Ref returns the sha a ref points to or "" when it doesn't exist.
+func (s *Server) Ref(repo *Repo, ref string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return repo.Refs[ref]
+}
+
+// Commit returns a commit created with the Git Data API or nil when there is none.
+func (s *Server) Commit(sha string) *Commit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	commit := s.commits[sha]
+	if commit == nil {
+		return nil
+	}
+	c := *commit
+	c.Files = maps.Clone(commit.Files)
+	return &c
+}
+
+// Tag returns a tag object created with the Git Data API or nil when there is none.
+func (s *Server) Tag(sha string) *Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag := s.tags[sha]
+	if tag == nil {
+		return nil
+	}
+	t := *tag
+	return &t
+}
+
+// InstallationTokens returns how many installation tokens were created.
+func (s *Server) InstallationTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.installationTokens)
+}
+
+// AddRelease adds an existing release to a repository.
+func (s *Server) AddRelease(repo *Repo, rel Release) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	rel.ID = s.lastID
+	if rel.Assets == nil {
+		rel.Assets = map[string]string{}
+	}
+	repo.Releases = append(repo.Releases, &rel)
+}
+
+// Releases returns a copy of a repository's releases.
+func (s *Server) Releases(repo *Repo) []Release {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Release, len(repo.Releases))
+	for i, rel := range repo.Releases {
+		result[i] = *rel
+		result[i].Assets = maps.Clone(rel.Assets)
+	}
+	return result
+}
+
+func (s *Server) htmlURL(repo *Repo) string {
+	return s.URL + "/" + repo.Owner + "/" + repo.Name
+}
+
+func (s *Server) pullJSON(repo *Repo, pull *Pull) map[string]any {
+	labels := make([]map[string]any, len(pull.Labels))
+	for i, label := range pull.Labels {
+		labels[i] = map[string]any{"name": label}
+	}
+	state := "closed"
+	if pull.Open {
+		state = "open"
+	}
+	result := map[string]any{
+		"number":   pull.Number,
+		"title":    pull.Title,
+		"body":     pull.Body,
+		"state":    state,
+		"html_url": fmt.Sprintf("%s/pull/%d", s.htmlURL(repo), pull.Number),
+		"user":     map[string]any{"login": pull.Author},
+		"labels":   labels,
+		"head":     map[string]any{"ref": pull.Head},
+		"base":     map[string]any{"ref": pull.Base, "sha": resolve(repo, pull.Base)},
+	}
+	if pull.MergeCommitSha != "" {
+		result["merge_commit_sha"] = pull.MergeCommitSha
+	}
+	if pull.Merged {
+		result["merged_at"] = "2024-01-01T00:00:00Z"
+	}
+	return result
+}
+
+type repoHandler func(w http.ResponseWriter, r *http.Request, repo *Repo)
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(method, path string, h repoHandler) {
+		for _, prefix := range []string{"/api/v3/repos/{owner}/{repo}", "/api/uploads/repos/{owner}/{repo}"} {
+			if strings.HasPrefix(path, "/releases/{id}/assets") != strings.HasPrefix(prefix, "/api/uploads") {
+				continue
+			}
+			mux.HandleFunc(method+" "+prefix+path, func(w http.ResponseWriter, r *http.Request) {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if !s.authorized(r) {
+					writeError(w, http.StatusUnauthorized, "Bad credentials")
+					return
+				}
+				repo := s.repos[r.PathValue("owner")+"/"+r.PathValue("repo")]
+				if repo == nil {
+					writeError(w, http.StatusNotFound, "Not Found")
+					return
+				}
+				h(w, r, repo)
+			})
+		}
+	}
+	mux.HandleFunc("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			writeError(w, http.StatusUnauthorized, "This endpoint requires you to be authenticated.")
+			return
+		}
+		writeError(w, http.StatusNotFound, "Not Found")
+	})
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/installation", s.appHandler(s.findInstallation))
+	mux.HandleFunc("POST /api/v3/app/installations/{id}/access_tokens", s.appHandler(s.createInstallationToken))
+	handle("GET", "/commits/{sha}/pulls", s.listCommitPulls)
+	handle("GET", "/compare/{basehead}", s.compare)
+	handle("GET", "/pulls", s.listPulls)
+	handle("POST", "/pulls", s.createPull)
+	handle("GET", "/pulls/{number}", s.getPull)
+	handle("PATCH", "/pulls/{number}", s.editPull)
+	handle("GET", "/pulls/{number}/commits", s.getPullCommits)
+	handle("POST", "/issues/{number}/labels", s.addLabels)
+	handle("POST", "/git/blobs", s.createBlob)
+	handle("POST", "/git/trees", s.createTree)
+	handle("GET", "/git/commits/{sha}", s.getGitCommit)
+	handle("POST", "/git/commits", s.createGitCommit)
+	handle("POST", "/git/tags", s.createTag)
+	handle("GET", "/git/ref/{ref...}", s.getRef)
+	handle("POST", "/git/refs", s.createRef)
+	handle("PATCH", "/git/refs/{ref...}", s.updateRef)
+	handle("DELETE", "/git/refs/{ref...}", s.deleteRef)
+	handle("GET", "/releases", s.listReleases)
+	handle("POST", "/releases", s.createRelease)
+	handle("POST", "/releases/generate-notes", s.generateNotes)
+	handle("PATCH", "/releases/{id}", s.editRelease)
+	handle("DELETE", "/releases/{id}", s.deleteRelease)
+	handle("POST", "/releases/{id}/assets", s.uploadAsset)
+	return mux
+}
+
+// authorized returns true when r has Token or an unexpired installation token.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	expires, isInstallationToken := s.installationTokens[token]
+	return token == s.Token || isInstallationToken && time.Now().Before(expires)
+}
+
+// appHandler wraps h to require a JWT for App.
+func (s *Server) appHandler(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.App == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		message := s.jwtError(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if message != "" {
+			writeError(w, http.StatusUnauthorized, message)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// jwtError returns the message GitHub responds with when jwt isn't an unexpired RS256 token issued by App. It is
+// empty when jwt is valid.
+func (s *Server) jwtError(jwt string) string {
+	const undecodable = "A JSON web token could not be decoded"
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return undecodable
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return undecodable
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(s.App.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		return undecodable
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return undecodable
+	}
+	var claims struct {
+		Iat int64 `json:"iat"`
+		Exp int64 `json:"exp"`
+		Iss int64 `json:"iss"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return undecodable
+	}
+	now := time.Now().Unix()
+	switch {
+	case claims.Iss != s.App.ID:
+		return "Integration not found"
+	case claims.Iat > now+60 || claims.Exp <= now || claims.Exp-claims.Iat > 600:
+		return "'Expiration time' claim ('exp') is too far in the future"
+	}
+	return ""
+}
+
+func (s *Server) findInstallation(w http.ResponseWriter, r *http.Request) {
+	if s.repos[r.PathValue("owner")+"/"+r.PathValue("repo")] == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": s.App.InstallationID, "app_id": s.App.ID})
+}
+
+func (s *Server) createInstallationToken(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") != strconv.FormatInt(s.App.InstallationID, 10) {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	buf := make([]byte, 20)
+	_, err := rand.Read(buf)
+	if err != nil {
+		panic(err)
+	}
+	token := "ghs_" + hex.EncodeToString(buf)
+	lifetime := s.App.TokenLifetime
+	if lifetime == 0 {
+		lifetime = time.Hour
+	}
+	expires := time.Now().Add(lifetime)
+	s.installationTokens[token] = expires
+	writeJSON(w, http.StatusCreated, map[string]any{"token": token, "expires_at": expires.UTC().Format(time.RFC3339)})
+}
+
+// listCommitPulls finds pull requests by merge commit or by one of their commits like GitHub does.
+func (s *Server) listCommitPulls(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	sha := r.PathValue("sha")
+	pulls := []map[string]any{}
+	for _, pull := range repo.Pulls {
+		if pull.MergeCommitSha == sha || slices.Contains(pull.Commits, sha) {
+			pulls = append(pulls, s.pullJSON(repo, pull))
+		}
+	}
+	writeJSON(w, http.StatusOK, pulls)
+}
+
+func (s *Server) compare(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	base, head, ok := strings.Cut(r.PathValue("basehead"), "...")
+	from := slices.Index(repo.Commits, resolve(repo, base))
+	to := slices.Index(repo.Commits, resolve(repo, head))
+	if !ok || from < 0 || to < 0 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	commits := []map[string]any{}
+	for i := from + 1; i <= to; i++ {
+		commits = append(commits, map[string]any{"sha": repo.Commits[i]})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ahead_by":      len(commits),
+		"behind_by":     max(from-to, 0),
+		"total_commits": len(commits),
+		"commits":       commits,
+	})
+}
+
+// resolve returns the sha of a branch or tag name. Anything else is returned as is.
+func resolve(repo *Repo, name string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if sha, ok := repo.Refs[prefix+name]; ok {
+			return sha
+		}
+	}
+	return name
+}
+
+func (s *Server) listPulls(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	query := r.URL.Query()
+	state := query.Get("state")
+	_, head, _ := strings.Cut(query.Get("head"), ":")
+	pulls := []map[string]any{}
+	for _, pull := range repo.Pulls {
+		switch {
+		case state != "all" && pull.Open != (state == "" || state == "open"):
+		case head != "" && pull.Head != head:
+		case query.Get("base") != "" && pull.Base != query.Get("base"):
+		default:
+			pulls = append(pulls, s.pullJSON(repo, pull))
+		}
+	}
+	writeJSON(w, http.StatusOK, pulls)
+}
+
+func (s *Server) createPull(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Title == "" || req.Head == "" || req.Base == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	if repo.Refs["refs/heads/"+req.Head] == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: head does not exist")
+		return
+	}
+	number := 1
+	for _, pull := range repo.Pulls {
+		number = max(number, pull.Number+1)
+	}
+	pull := &Pull{Number: number, Title: req.Title, Body: req.Body, Head: req.Head, Base: req.Base, Open: true}
+	repo.Pulls = append(repo.Pulls, pull)
+	writeJSON(w, http.StatusCreated, s.pullJSON(repo, pull))
+}
+
+func (s *Server) findPull(w http.ResponseWriter, r *http.Request, repo *Repo) *Pull {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err == nil {
+		for _, pull := range repo.Pulls {
+			if pull.Number == number {
+				return pull
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+	return nil
+}
+
+func (s *Server) getPull(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	pull := s.findPull(w, r, repo)
+	if pull != nil {
+		writeJSON(w, http.StatusOK, s.pullJSON(repo, pull))
+	}
+}
+
+func (s *Server) editPull(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	pull := s.findPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	var req struct {
+		Title *string `json:"title"`
+		Body  *string `json:"body"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	if req.Title != nil {
+		pull.Title = *req.Title
+	}
+	if req.Body != nil {
+		pull.Body = *req.Body
+	}
+	writeJSON(w, http.StatusOK, s.pullJSON(repo, pull))
+}
+
+func (s *Server) getPullCommits(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	pull := s.findPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	commits := []map[string]any{}
+	for _, sha := range pull.Commits {
+		commits = append(commits, map[string]any{"sha": sha})
+	}
+	writeJSON(w, http.StatusOK, commits)
+}
+
+func (s *Server) addLabels(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	pull := s.findPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	var labels []string
+	err := json.NewDecoder(r.Body).Decode(&labels)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	result := []map[string]any{}
+	for _, label := range labels {
+		if !slices.Contains(pull.Labels, label) {
+			pull.Labels = append(pull.Labels, label)
+		}
+	}
+	for _, label := range pull.Labels {
+		result = append(result, map[string]any{"name": label})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// objectID returns a sha1 id for an object so that equal objects get equal ids.
+func objectID(kind string, v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha1.Sum(append([]byte(kind+"\x00"), data...)) //nolint:gosec // git object ids are sha1
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) createBlob(w http.ResponseWriter, r *http.Request, _ *Repo) {
+	var req struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	content := req.Content
+	if req.Encoding == "base64" {
+		var decoded []byte
+		decoded, err = base64.StdEncoding.DecodeString(req.Content)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed: invalid base64")
+			return
+		}
+		content = string(decoded)
+	}
+	sha := objectID("blob", content)
+	s.blobs[sha] = content
+	writeJSON(w, http.StatusCreated, map[string]any{"sha": sha})
+}
+
+func (s *Server) createTree(w http.ResponseWriter, r *http.Request, _ *Repo) {
+	var req struct {
+		BaseTree string `json:"base_tree"`
+		Tree     []struct {
+			Path    string  `json:"path"`
+			Type    string  `json:"type"`
+			SHA     *string `json:"sha"`
+			Content *string `json:"content"`
+		} `json:"tree"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	files := map[string]string{}
+	if req.BaseTree != "" {
+		base, ok := s.trees[req.BaseTree]
+		if !ok {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed: base_tree does not exist")
+			return
+		}
+		maps.Copy(files, base)
+	}
+	for _, entry := range req.Tree {
+		switch {
+		case entry.Content != nil:
+			files[entry.Path] = *entry.Content
+		case entry.SHA == nil:
+			delete(files, entry.Path)
+		default:
+			content, ok := s.blobs[*entry.SHA]
+			if !ok {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed: blob does not exist")
+				return
+			}
+			files[entry.Path] = content
+		}
+	}
+	sha := objectID("tree", files)
+	s.trees[sha] = files
+	writeJSON(w, http.StatusCreated, map[string]any{"sha": sha})
+}
+
+// treeOf returns the tree of a commit. Commits from Repo.Commits have an empty tree.
+func (s *Server) treeOf(repo *Repo, sha string) (string, bool) {
+	if commit := s.commits[sha]; commit != nil {
+		return objectID("tree", commit.Files), true
+	}
+	if !slices.Contains(repo.Commits, sha) {
+		return "", false
+	}
+	tree := objectID("tree", map[string]string{})
+	s.trees[tree] = map[string]string{}
+	return tree, true
+}
+
+func (s *Server) getGitCommit(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	sha := r.PathValue("sha")
+	tree, ok := s.treeOf(repo, sha)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sha": sha, "tree": map[string]any{"sha": tree}})
+}
+
+func (s *Server) createGitCommit(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req struct {
+		Message string   `json:"message"`
+		Tree    string   `json:"tree"`
+		Parents []string `json:"parents"`
+		Author  *struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	files, ok := s.trees[req.Tree]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: tree does not exist")
+		return
+	}
+	for _, parent := range req.Parents {
+		if _, ok = s.treeOf(repo, parent); !ok {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed: parent does not exist")
+			return
+		}
+	}
+	commit := &Commit{Message: req.Message, Parents: req.Parents, Files: maps.Clone(files)}
+	if req.Author != nil {
+		commit.Author = req.Author.Name + " <" + req.Author.Email + ">"
+	}
+	commit.Sha = objectID("commit", commit)
+	s.commits[commit.Sha] = commit
+	writeJSON(w, http.StatusCreated, map[string]any{"sha": commit.Sha, "tree": map[string]any{"sha": req.Tree}})
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req struct {
+		Tag     string `json:"tag"`
+		Message string `json:"message"`
+		Object  string `json:"object"`
+		Type    string `json:"type"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Type != "commit" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	if _, ok := s.treeOf(repo, req.Object); !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: object does not exist")
+		return
+	}
+	tag := &Tag{Name: req.Tag, Message: req.Message, Object: req.Object}
+	tag.Sha = objectID("tag", tag)
+	s.tags[tag.Sha] = tag
+	writeJSON(w, http.StatusCreated, map[string]any{"sha": tag.Sha, "tag": tag.Name, "message": tag.Message})
+}
+
+func (s *Server) refJSON(ref, sha string) map[string]any {
+	return map[string]any{"ref": ref, "object": map[string]any{"sha": sha}}
+}
+
+func (s *Server) getRef(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	ref := "refs/" + r.PathValue("ref")
+	sha, ok := repo.Refs[ref]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.refJSON(ref, sha))
+}
+
+func (s *Server) createRef(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || !strings.HasPrefix(req.Ref, "refs/") {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	if _, ok := repo.Refs[req.Ref]; ok {
+		writeError(w, http.StatusUnprocessableEntity, "Reference already exists")
+		return
+	}
+	repo.Refs[req.Ref] = req.SHA
+	writeJSON(w, http.StatusCreated, s.refJSON(req.Ref, req.SHA))
+}
+
+func (s *Server) updateRef(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	ref := "refs/" + r.PathValue("ref")
+	var req struct {
+		SHA   string `json:"sha"`
+		Force bool   `json:"force"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	old, ok := repo.Refs[ref]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Reference does not exist")
+		return
+	}
+	if !req.Force && !s.isAncestor(repo, old, req.SHA) {
+		writeError(w, http.StatusUnprocessableEntity, "Update is not a fast forward")
+		return
+	}
+	repo.Refs[ref] = req.SHA
+	writeJSON(w, http.StatusOK, s.refJSON(ref, req.SHA))
+}
+
+// isAncestor returns true if ancestor is reachable from sha.
+func (s *Server) isAncestor(repo *Repo, ancestor, sha string) bool {
+	if ancestor == sha {
+		return true
+	}
+	if commit := s.commits[sha]; commit != nil {
+		return slices.ContainsFunc(commit.Parents, func(parent string) bool {
+			return s.isAncestor(repo, ancestor, parent)
+		})
+	}
+	a, b := slices.Index(repo.Commits, ancestor), slices.Index(repo.Commits, sha)
+	return a >= 0 && a <= b
+}
+
+func (s *Server) deleteRef(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	ref := "refs/" + r.PathValue("ref")
+	if _, ok := repo.Refs[ref]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Reference does not exist")
+		return
+	}
+	delete(repo.Refs, ref)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) releaseJSON(repo *Repo, rel *Release) map[string]any {
+	return map[string]any{
+		"id":         rel.ID,
+		"tag_name":   rel.TagName,
+		"name":       rel.Name,
+		"body":       rel.Body,
+		"draft":      rel.Draft,
+		"prerelease": rel.Prerelease,
+		"html_url":   fmt.Sprintf("%s/releases/tag/%s", s.htmlURL(repo), rel.TagName),
+		"upload_url": fmt.Sprintf("%s/api/uploads/repos/%s/%s/releases/%d/assets{?name,label}", s.URL, repo.Owner, repo.Name, rel.ID),
+	}
+}
+
+func (s *Server) listReleases(w http.ResponseWriter, _ *http.Request, repo *Repo) {
+	releases := []map[string]any{}
+	for _, rel := range slices.Backward(repo.Releases) {
+		releases = append(releases, s.releaseJSON(repo, rel))
+	}
+	writeJSON(w, http.StatusOK, releases)
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req struct {
+		TagName    string `json:"tag_name"`
+		Name       string `json:"name"`
+		Body       string `json:"body"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		MakeLatest string `json:"make_latest"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.TagName == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	s.lastID++
+	rel := &Release{
+		ID:         s.lastID,
+		TagName:    req.TagName,
+		Name:       req.Name,
+		Body:       req.Body,
+		Draft:      req.Draft,
+		Prerelease: req.Prerelease,
+		MakeLatest: req.MakeLatest,
+		Assets:     map[string]string{},
+	}
+	repo.Releases = append(repo.Releases, rel)
+	writeJSON(w, http.StatusCreated, s.releaseJSON(repo, rel))
+}
+
+func (s *Server) generateNotes(w http.ResponseWriter, r *http.Request, _ *Repo) {
+	var req struct {
+		TagName         string `json:"tag_name"`
+		PreviousTagName string `json:"previous_tag_name"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.TagName == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name": req.TagName,
+		"body": fmt.Sprintf("changes from %s to %s", req.PreviousTagName, req.TagName),
+	})
+}
+
+func (s *Server) findRelease(w http.ResponseWriter, r *http.Request, repo *Repo) int {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err == nil {
+		for i, rel := range repo.Releases {
+			if rel.ID == id {
+				return i
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+	return -1
+}
+
+func (s *Server) editRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	idx := s.findRelease(w, r, repo)
+	if idx < 0 {
+		return
+	}
+	var req struct {
+		Draft      *bool   `json:"draft"`
+		MakeLatest *string `json:"make_latest"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	rel := repo.Releases[idx]
+	if req.Draft != nil {
+		rel.Draft = *req.Draft
+	}
+	if req.MakeLatest != nil {
+		rel.MakeLatest = *req.MakeLatest
+	}
+	writeJSON(w, http.StatusOK, s.releaseJSON(repo, rel))
+}
+
+func (s *Server) deleteRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	idx := s.findRelease(w, r, repo)
+	if idx < 0 {
+		return
+	}
+	repo.Releases = slices.Delete(repo.Releases, idx, idx+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	idx := s.findRelease(w, r, repo)
+	if idx < 0 {
+		return
+	}
+	name := r.URL.Query().Get("name")
+	content, err := io.ReadAll(r.Body)
+	if err != nil || name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	repo.Releases[idx].Assets[name] = string(content)
+	writeJSON(w, http.StatusCreated, map[string]any{"name": name, "size": len(content)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
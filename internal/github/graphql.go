@@ -0,0 +1,224 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// ErrGraphQLUnsupported is returned by ListMergedPullsForCommits when the GraphQL API can't be used. Older GitHub
+// Enterprise Server versions lack some of the fields it needs, and it requires authentication.
+var ErrGraphQLUnsupported = errors.New("graphql api doesn't support looking up pull requests for commits")
+
+// commitBatchSize is the number of commits looked up in each GraphQL request.
+const commitBatchSize = 100
+
+const commitPullsFragment = `
+fragment commitPulls on Commit {
+  associatedPullRequests(first: 10) {
+    pageInfo { hasNextPage }
+    nodes {
+      number
+      title
+      url
+      state
+      merged
+      mergeCommit { oid }
+      author { login }
+      labels(first: 100) {
+        pageInfo { hasNextPage }
+        nodes { name }
+      }
+    }
+  }
+}`
+
+type pageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+}
+
+type graphqlCommit struct {
+	AssociatedPullRequests struct {
+		PageInfo pageInfo `json:"pageInfo"`
+		Nodes    []struct {
+			Number      int    `json:"number"`
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			State       string `json:"state"`
+			Merged      bool   `json:"merged"`
+			MergeCommit *struct {
+				Oid string `json:"oid"`
+			} `json:"mergeCommit"`
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			Labels struct {
+				PageInfo pageInfo `json:"pageInfo"`
+				Nodes    []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"labels"`
+		} `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+type graphqlError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code string `json:"code"`
+	} `json:"extensions"`
+}
+
+// ListMergedPullsForCommits is ListMergedPullsForCommit for many commits. It uses the GraphQL API to look up commits
+// in batches. Commits with too many pull requests or labels for one GraphQL request are looked up with the REST API.
+// With a cache, finished commits are served from it and commits with open pulls are revalidated with the REST API
+// because GraphQL has no conditional requests.
+func (g *Client) ListMergedPullsForCommits(
+	ctx context.Context,
+	owner, repo string,
+	shas []string,
+) (map[string][]BasePull, error) {
+	if g.anonymous {
+		return nil, fmt.Errorf("%w: graphql api requires a token", ErrGraphQLUnsupported)
+	}
+	result := make(map[string][]BasePull, len(shas))
+	var uncached, open []string
+	for _, sha := range shas {
+		cached := g.cache.get(owner, repo, sha)
+		switch {
+		case cached == nil:
+			uncached = append(uncached, sha)
+		case cached.Final:
+			result[sha] = cached.Pulls
+		default:
+			open = append(open, sha)
+		}
+	}
+	for start := 0; start < len(uncached); start += commitBatchSize {
+		batch := uncached[start:min(start+commitBatchSize, len(uncached))]
+		commits, err := g.queryCommits(ctx, owner, repo, batch)
+		if err != nil {
+			return nil, err
+		}
+		for i, sha := range batch {
+			commit := commits[fmt.Sprintf("c%d", i)]
+			if commit == nil {
+				continue
+			}
+			entry, truncated := commit.cacheEntry()
+			if truncated {
+				open = append(open, sha)
+				continue
+			}
+			g.cache.store(owner, repo, sha, entry)
+			result[sha] = entry.Pulls
+		}
+	}
+	for _, sha := range open {
+		pulls, err := g.ListMergedPullsForCommit(ctx, owner, repo, sha)
+		if err != nil {
+			return nil, err
+		}
+		result[sha] = pulls
+	}
+	return result, nil
+}
+
+// cacheEntry returns the commit's merged pull requests as a cache entry. truncated is true when there were more pull
+// requests or labels than the query fetched. The entry has no ETag, so a commit with open pulls is fetched in full
+// the next time.
+func (c *graphqlCommit) cacheEntry() (_ *commitCacheEntry, truncated bool) {
+	// a commit without pulls may still get one
+	entry := commitCacheEntry{Final: len(c.AssociatedPullRequests.Nodes) > 0}
+	truncated = c.AssociatedPullRequests.PageInfo.HasNextPage
+	for _, node := range c.AssociatedPullRequests.Nodes {
+		if node.State == "OPEN" {
+			entry.Final = false
+		}
+		if !node.Merged || node.MergeCommit == nil {
+			continue
+		}
+		pull := BasePull{
+			Number:         node.Number,
+			MergeCommitSha: node.MergeCommit.Oid,
+			Labels:         make([]string, len(node.Labels.Nodes)),
+			Title:          node.Title,
+			URL:            node.URL,
+		}
+		if node.Author != nil {
+			pull.Author = node.Author.Login
+		}
+		for i, label := range node.Labels.Nodes {
+			pull.Labels[i] = label.Name
+		}
+		truncated = truncated || node.Labels.PageInfo.HasNextPage
+		entry.Pulls = append(entry.Pulls, pull)
+	}
+	return &entry, truncated
+}
+
+// queryCommits looks up commits in a single GraphQL request. The result is keyed by the aliases c0, c1 and so on in
+// the same order as shas. Commits that don't exist are nil.
+func (g *Client) queryCommits(ctx context.Context, owner, repo string, shas []string) (map[string]*graphqlCommit, error) {
+	var params, fields strings.Builder
+	variables := map[string]any{"owner": owner, "name": repo}
+	for i, sha := range shas {
+		fmt.Fprintf(&params, ", $c%d: GitObjectID!", i)
+		fmt.Fprintf(&fields, "    c%d: object(oid: $c%d) { ...commitPulls }\n", i, i)
+		variables[fmt.Sprintf("c%d", i)] = sha
+	}
+	query := fmt.Sprintf("query($owner: String!, $name: String!%s) {\n  repository(owner: $owner, name: $name) {\n%s  }\n}\n%s",
+		params.String(), fields.String(), commitPullsFragment)
+	req, err := g.client.NewRequest(http.MethodPost, g.graphqlURL(), map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data struct {
+			Repository map[string]*graphqlCommit `json:"repository"`
+		} `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+	_, err = g.client.Do(ctx, req, &resp)
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if errors.As(err, &errResp) {
+			switch errResp.Response.StatusCode {
+			case http.StatusNotFound:
+				return nil, ErrGraphQLUnsupported
+			case http.StatusUnauthorized, http.StatusForbidden:
+				// the REST API allows some requests the GraphQL API doesn't, such as unauthenticated ones
+				return nil, fmt.Errorf("%w: %w", ErrGraphQLUnsupported, err)
+			}
+		}
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			if e.Extensions.Code == "undefinedField" {
+				return nil, fmt.Errorf("%w: %s", ErrGraphQLUnsupported, e.Message)
+			}
+			msgs[i] = e.Message
+		}
+		return nil, fmt.Errorf("graphql: %s", strings.Join(msgs, "; "))
+	}
+	return resp.Data.Repository, nil
+}
+
+// graphqlURL returns the GraphQL endpoint. GitHub Enterprise Server serves it at /api/graphql instead of under the
+// /api/v3/ REST base.
+func (g *Client) graphqlURL() string {
+	base := g.client.BaseURL.String()
+	if strings.HasSuffix(base, "/api/v3/") {
+		return strings.TrimSuffix(base, "v3/") + "graphql"
+	}
+	return base + "graphql"
+}
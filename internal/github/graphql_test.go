@@ -0,0 +1,155 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListMergedPullsForCommits(t *testing.T) {
+	t.Parallel()
+
+	newClient := func(t *testing.T, handler http.Handler) *Client {
+		t.Helper()
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		client, err := NewClient(server.URL+"/api/v3/", "token", "release-train/test")
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("batches", func(t *testing.T) {
+		t.Parallel()
+		var batches []int
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Variables map[string]any `json:"variables"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "orgname", req.Variables["owner"])
+			require.Equal(t, "repo", req.Variables["name"])
+			repository := map[string]any{}
+			for i := 0; req.Variables[fmt.Sprintf("c%d", i)] != nil; i++ {
+				alias := fmt.Sprintf("c%d", i)
+				switch req.Variables[alias] {
+				case "merged":
+					repository[alias] = map[string]any{"associatedPullRequests": map[string]any{
+						"pageInfo": map[string]any{"hasNextPage": false},
+						"nodes": []any{
+							map[string]any{
+								"number":      1,
+								"title":       "add foo",
+								"url":         "https://github.com/orgname/repo/pull/1",
+								"merged":      true,
+								"mergeCommit": map[string]any{"oid": "m1"},
+								"author":      map[string]any{"login": "alice"},
+								"labels": map[string]any{
+									"pageInfo": map[string]any{"hasNextPage": false},
+									"nodes":    []any{map[string]any{"name": "semver:minor"}},
+								},
+							},
+							map[string]any{"number": 2, "merged": false},
+						},
+					}}
+				case "many":
+					repository[alias] = map[string]any{"associatedPullRequests": map[string]any{
+						"pageInfo": map[string]any{"hasNextPage": true},
+					}}
+				default:
+					repository[alias] = map[string]any{}
+				}
+			}
+			batches = append(batches, len(repository))
+			writeJSON(t, w, map[string]any{"data": map[string]any{"repository": repository}})
+		})
+		mux.HandleFunc("GET /api/v3/repos/orgname/repo/commits/many/pulls", func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(t, w, []any{map[string]any{"number": 3, "merge_commit_sha": "m3"}})
+		})
+		client := newClient(t, mux)
+		shas := make([]string, 150)
+		for i := range shas {
+			shas[i] = fmt.Sprintf("sha%d", i)
+		}
+		shas[0], shas[120] = "merged", "many"
+		got, err := client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", shas)
+		require.NoError(t, err)
+		require.Len(t, got, 150)
+		require.Equal(t, []BasePull{{
+			Number:         1,
+			MergeCommitSha: "m1",
+			Labels:         []string{"semver:minor"},
+			Title:          "add foo",
+			Author:         "alice",
+			URL:            "https://github.com/orgname/repo/pull/1",
+		}}, got["merged"])
+		require.Equal(t, []BasePull{{Number: 3, MergeCommitSha: "m3", Labels: []string{}}}, got["many"])
+		require.Empty(t, got["sha1"])
+		require.Equal(t, []int{100, 50}, batches)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		client := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(t, w, map[string]any{"errors": []any{map[string]any{
+				"message":    "Field 'associatedPullRequests' doesn't exist on type 'Commit'",
+				"extensions": map[string]any{"code": "undefinedField"},
+			}}})
+		}))
+		_, err := client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", []string{"sha"})
+		require.ErrorIs(t, err, ErrGraphQLUnsupported)
+	})
+
+	t.Run("no graphql endpoint", func(t *testing.T) {
+		t.Parallel()
+		client := newClient(t, http.NotFoundHandler())
+		_, err := client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", []string{"sha"})
+		require.ErrorIs(t, err, ErrGraphQLUnsupported)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		t.Parallel()
+		client := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			writeJSON(t, w, map[string]any{"message": "Resource not accessible by integration"})
+		}))
+		_, err := client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", []string{"sha"})
+		require.ErrorIs(t, err, ErrGraphQLUnsupported)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		t.Parallel()
+		// GitHub answers 401 to GraphQL requests without a token
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		t.Cleanup(server.Close)
+		client, err := NewClient(server.URL+"/api/v3/", "", "release-train/test")
+		require.NoError(t, err)
+		_, err = client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", []string{"sha"})
+		require.ErrorIs(t, err, ErrGraphQLUnsupported)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		client := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(t, w, map[string]any{"errors": []any{
+				map[string]any{"message": "first"},
+				map[string]any{"message": "second"},
+			}})
+		}))
+		_, err := client.ListMergedPullsForCommits(t.Context(), "orgname", "repo", []string{"sha"})
+		require.EqualError(t, err, "graphql: first; second")
+	})
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
@@ -0,0 +1,417 @@
+// Package gitlab implements release-train's forge client for GitLab using the GitLab REST API.
+package gitlab
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/willabides/release-train/v3/internal/github"
+)
+
+// DefaultBaseURL is the API URL for gitlab.com.
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Client is a GitLab client. Merge requests stand in for pull requests.
+//
+// GitLab doesn't have draft releases, so CreateRelease only records the release and PublishRelease creates it. Assets
+// are uploaded to the generic package registry and linked from the release when it is published.
+type Client struct {
+	baseURL    string
+	token      string
+	userAgent  string
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	lastID   int64
+	releases map[int64]*pendingRelease
+}
+
+type pendingRelease struct {
+	project   string
+	tag       string
+	body      string
+	uploadURL string
+	links     []releaseLink
+}
+
+type releaseLink struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type"`
+}
+
+type mergeRequest struct {
+	IID             int      `json:"iid"`
+	State           string   `json:"state"`
+	Title           string   `json:"title"`
+	WebURL          string   `json:"web_url"`
+	MergeCommitSHA  string   `json:"merge_commit_sha"`
+	SquashCommitSHA string   `json:"squash_commit_sha"`
+	Labels          []string `json:"labels"`
+	Author          struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	DiffRefs struct {
+		BaseSha string `json:"base_sha"`
+	} `json:"diff_refs"`
+}
+
+func (m *mergeRequest) basePull() github.BasePull {
+	return github.BasePull{
+		Number:         m.IID,
+		MergeCommitSha: cmp.Or(m.MergeCommitSHA, m.SquashCommitSHA),
+		Labels:         m.Labels,
+		Title:          m.Title,
+		Author:         m.Author.Username,
+		URL:            m.WebURL,
+		BaseSha:        m.DiffRefs.BaseSha,
+	}
+}
+
+type commit struct {
+	ID string `json:"id"`
+}
+
+func NewClient(baseURL, token, userAgent string) (*Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	_, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		userAgent:  userAgent,
+		httpClient: http.DefaultClient,
+		releases:   map[int64]*pendingRelease{},
+	}, nil
+}
+
+// projectURL returns the API URL for a project endpoint. GitLab projects may be in nested groups, so owner can
+// contain slashes.
+func (g *Client) projectURL(owner, repo string, elem ...string) string {
+	u := g.baseURL + "/projects/" + url.PathEscape(owner+"/"+repo)
+	for _, e := range elem {
+		u += "/" + url.PathEscape(e)
+	}
+	return u
+}
+
+func (g *Client) do(ctx context.Context, method, reqURL string, body io.Reader, out any) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept", "application/json")
+	switch body.(type) {
+	case nil:
+	case *os.File:
+		req.Header.Set("Content-Type", "application/octet-stream")
+	default:
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, req.URL.Redacted(), resp.Status, bytes.TrimSpace(respBody))
+	}
+	if out != nil {
+		err = json.Unmarshal(respBody, out)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, req.URL.Redacted(), err)
+		}
+	}
+	return resp, nil
+}
+
+// getPages gets every page of a list endpoint.
+func getPages[T any](ctx context.Context, g *Client, reqURL string, query url.Values) ([]T, error) {
+	const pageSize = 100
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(pageSize))
+	var result []T
+	page := "1"
+	for page != "" {
+		query.Set("page", page)
+		var items []T
+		resp, err := g.do(ctx, http.MethodGet, reqURL+"?"+query.Encode(), nil, &items)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return result, nil
+}
+
+func (g *Client) ListMergedPullsForCommit(ctx context.Context, owner, repo, sha string) ([]github.BasePull, error) {
+	mrs, err := getPages[mergeRequest](ctx, g, g.projectURL(owner, repo, "repository", "commits", sha, "merge_requests"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []github.BasePull
+	for i := range mrs {
+		if mrs[i].State != "merged" {
+			continue
+		}
+		pull := mrs[i].basePull()
+		// fast-forward merges don't have a merge commit, so the commit itself was merged
+		if pull.MergeCommitSha == "" {
+			pull.MergeCommitSha = sha
+		}
+		result = append(result, pull)
+	}
+	return result, nil
+}
+
+// CompareCommits returns a commit comparison that includes up to count commits. If count is -1, all commits are
+// included. If count is 0, no commits are included. GitLab's compare endpoint doesn't report how far the refs have
+// diverged, so BehindBy comes from comparing in the other direction.
+func (g *Client) CompareCommits(
+	ctx context.Context,
+	owner, repo, base, head string,
+	count int,
+) (*github.CommitComparison, error) {
+	ahead, err := g.compare(ctx, owner, repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	behind, err := g.compare(ctx, owner, repo, head, base)
+	if err != nil {
+		return nil, err
+	}
+	result := github.CommitComparison{
+		AheadBy:  len(ahead),
+		BehindBy: len(behind),
+	}
+	if count >= 0 && count < len(ahead) {
+		ahead = ahead[:count]
+	}
+	for _, c := range ahead {
+		result.Commits = append(result.Commits, c.ID)
+	}
+	return &result, nil
+}
+
+func (g *Client) compare(ctx context.Context, owner, repo, from, to string) ([]commit, error) {
+	query := url.Values{"from": {from}, "to": {to}}
+	var comp struct {
+		Commits []commit `json:"commits"`
+	}
+	_, err := g.do(ctx, http.MethodGet, g.projectURL(owner, repo, "repository", "compare")+"?"+query.Encode(), nil, &comp)
+	if err != nil {
+		return nil, err
+	}
+	return comp.Commits, nil
+}
+
+// GenerateReleaseNotes returns a link to the comparison between the tags. GitLab can only generate notes from
+// commit trailers, which release-train doesn't require.
+func (g *Client) GenerateReleaseNotes(ctx context.Context, owner, repo, tag, prevTag string) (string, error) {
+	var project struct {
+		WebURL string `json:"web_url"`
+	}
+	_, err := g.do(ctx, http.MethodGet, g.projectURL(owner, repo), nil, &project)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("**Full Changelog**: %s/-/compare/%s...%s", project.WebURL, prevTag, tag), nil
+}
+
+// CreateRelease records a release to be created by PublishRelease. GitLab doesn't have prereleases, so prerelease
+// is ignored. The upload URL is the generic package for the release's assets.
+func (g *Client) CreateRelease(
+	ctx context.Context,
+	owner, repo, tag, body string,
+	_ bool,
+) (*github.RepoRelease, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastID++
+	rel := pendingRelease{
+		project:   owner + "/" + repo,
+		tag:       tag,
+		body:      body,
+		uploadURL: g.projectURL(owner, repo, "packages", "generic", path.Base(repo), packageVersion(tag)),
+	}
+	g.releases[g.lastID] = &rel
+	return &github.RepoRelease{
+		ID:        g.lastID,
+		UploadURL: rel.uploadURL,
+	}, nil
+}
+
+var invalidPackageVersionRE = regexp.MustCompile(`[^0-9A-Za-z._+-]+`)
+
+// packageVersion returns the generic package version for tag. Package versions can't have slashes or most other
+// punctuation, so prefixed tags like tools/v1.2.0 become tools-v1.2.0.
+func packageVersion(tag string) string {
+	return invalidPackageVersionRE.ReplaceAllString(tag, "-")
+}
+
+// UploadAsset uploads a file to the generic package at uploadURL and links it from the release.
+func (g *Client) UploadAsset(ctx context.Context, uploadURL, filename string) error {
+	rel := g.releaseByUploadURL(uploadURL)
+	if rel == nil {
+		return fmt.Errorf("invalid upload url: %s", uploadURL)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	name := filepath.Base(filename)
+	fileURL := uploadURL + "/" + url.PathEscape(name)
+	_, err = g.do(ctx, http.MethodPut, fileURL, file, nil)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rel.links = append(rel.links, releaseLink{Name: name, URL: fileURL, LinkType: "package"})
+	return nil
+}
+
+func (g *Client) releaseByUploadURL(uploadURL string) *pendingRelease {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, rel := range g.releases {
+		if rel.uploadURL == uploadURL {
+			return rel
+		}
+	}
+	return nil
+}
+
+// DeleteRelease forgets a release that hasn't been published. Uploaded assets stay in the package registry.
+func (g *Client) DeleteRelease(_ context.Context, _, _ string, id int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.releases[id] == nil {
+		return fmt.Errorf("unknown release: %d", id)
+	}
+	delete(g.releases, id)
+	return nil
+}
+
+// PublishRelease creates the release. GitLab shows the most recent release as latest, so makeLatest is only
+// validated.
+func (g *Client) PublishRelease(ctx context.Context, _, _, makeLatest string, id int64) (*github.RepoRelease, error) {
+	if !slices.Contains([]string{"", "legacy", "true", "false"}, makeLatest) {
+		return nil, fmt.Errorf("invalid makeLatest value: %s", makeLatest)
+	}
+	g.mu.Lock()
+	rel := g.releases[id]
+	g.mu.Unlock()
+	if rel == nil {
+		return nil, fmt.Errorf("unknown release: %d", id)
+	}
+	links := rel.links
+	if links == nil {
+		links = []releaseLink{}
+	}
+	body, err := json.Marshal(map[string]any{
+		"tag_name":    rel.tag,
+		"name":        rel.tag,
+		"description": rel.body,
+		"assets":      map[string]any{"links": links},
+	})
+	if err != nil {
+		return nil, err
+	}
+	releasesURL := g.baseURL + "/projects/" + url.PathEscape(rel.project) + "/releases"
+	var created struct {
+		Links struct {
+			Self string `json:"self"`
+		} `json:"_links"`
+	}
+	_, err = g.do(ctx, http.MethodPost, releasesURL, bytes.NewReader(body), &created)
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	delete(g.releases, id)
+	g.mu.Unlock()
+	return &github.RepoRelease{
+		ID:        id,
+		UploadURL: rel.uploadURL,
+		URL:       created.Links.Self,
+	}, nil
+}
+
+// ListReleases returns the project's releases. GitLab has no draft or prerelease releases, and releases are identified
+// by their tag, so only Tag and URL are set.
+func (g *Client) ListReleases(ctx context.Context, owner, repo string) ([]github.RepoRelease, error) {
+	releases, err := getPages[struct {
+		TagName string `json:"tag_name"`
+		Links   struct {
+			Self string `json:"self"`
+		} `json:"_links"`
+	}](ctx, g, g.projectURL(owner, repo, "releases"), nil)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]github.RepoRelease, len(releases))
+	for i, rel := range releases {
+		result[i] = github.RepoRelease{Tag: rel.TagName, URL: rel.Links.Self}
+	}
+	return result, nil
+}
+
+func (g *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*github.BasePull, error) {
+	var mr mergeRequest
+	_, err := g.do(ctx, http.MethodGet, g.projectURL(owner, repo, "merge_requests", strconv.Itoa(number)), nil, &mr)
+	if err != nil {
+		return nil, err
+	}
+	pull := mr.basePull()
+	return &pull, nil
+}
+
+func (g *Client) GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]string, error) {
+	commits, err := getPages[commit](ctx, g, g.projectURL(owner, repo, "merge_requests", strconv.Itoa(number), "commits"), nil)
+	if err != nil {
+		return nil, err
+	}
+	shas := make([]string, len(commits))
+	for i, c := range commits {
+		shas[i] = c.ID
+	}
+	return shas, nil
+}
@@ -0,0 +1,344 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github"
+)
+
+const (
+	testToken   = "secret"
+	testProject = "group/sub/repo"
+)
+
+var validPackageVersionRE = regexp.MustCompile(`^[0-9A-Za-z._+-]+$`)
+
+// fakeGitLab serves the parts of the GitLab API that Client uses for a single project. List endpoints return one item
+// per page to exercise pagination.
+type fakeGitLab struct {
+	mergeRequests map[int]mergeRequest
+	// commitMRs maps commit shas to the iids of merge requests containing them
+	commitMRs map[string][]int
+	mrCommits map[int][]string
+	// history lists commits oldest first. Commits are compared by their position in it.
+	history []string
+
+	mu       sync.Mutex
+	packages map[string]string
+	releases []map[string]any
+}
+
+func (f *fakeGitLab) handler(t *testing.T) http.Handler {
+	t.Helper()
+	mux := http.NewServeMux()
+	project := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("PRIVATE-TOKEN") != testToken {
+				http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if r.PathValue("id") != testProject {
+				http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("GET /api/v4/projects/{id}", project(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]string{"web_url": "https://gitlab.example/" + testProject})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/commits/{sha}/merge_requests", project(func(w http.ResponseWriter, r *http.Request) {
+		var mrs []mergeRequest
+		for _, iid := range f.commitMRs[r.PathValue("sha")] {
+			mrs = append(mrs, f.mergeRequests[iid])
+		}
+		writePage(t, w, r, mrs)
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/compare", project(func(w http.ResponseWriter, r *http.Request) {
+		from := f.position(r.URL.Query().Get("from"))
+		to := f.position(r.URL.Query().Get("to"))
+		if from < 0 || to < 0 {
+			http.Error(w, `{"message":"404 Ref Not Found"}`, http.StatusNotFound)
+			return
+		}
+		commits := []commit{}
+		for i := from + 1; i <= to; i++ {
+			commits = append(commits, commit{ID: f.history[i]})
+		}
+		writeJSON(t, w, map[string]any{"commits": commits})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}", project(func(w http.ResponseWriter, r *http.Request) {
+		iid, err := strconv.Atoi(r.PathValue("iid"))
+		require.NoError(t, err)
+		mr, ok := f.mergeRequests[iid]
+		if !ok {
+			http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(t, w, mr)
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}/commits", project(func(w http.ResponseWriter, r *http.Request) {
+		iid, err := strconv.Atoi(r.PathValue("iid"))
+		require.NoError(t, err)
+		var commits []commit
+		for _, sha := range f.mrCommits[iid] {
+			commits = append(commits, commit{ID: sha})
+		}
+		writePage(t, w, r, commits)
+	}))
+	mux.HandleFunc("PUT /api/v4/projects/{id}/packages/generic/{name}/{version}/{file}", project(func(w http.ResponseWriter, r *http.Request) {
+		if !validPackageVersionRE.MatchString(r.PathValue("version")) {
+			http.Error(w, `{"error":"version is invalid"}`, http.StatusBadRequest)
+			return
+		}
+		content, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		f.mu.Lock()
+		f.packages[r.PathValue("name")+"/"+r.PathValue("version")+"/"+r.PathValue("file")] = string(content)
+		f.mu.Unlock()
+		writeJSON(t, w, map[string]string{"message": "201 Created"})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{id}/releases", project(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		var releases []map[string]any
+		for _, release := range slices.Backward(f.releases) {
+			tag := release["tag_name"].(string)
+			releases = append(releases, map[string]any{
+				"tag_name": tag,
+				"_links":   map[string]any{"self": "https://gitlab.example/group/sub/repo/-/releases/" + tag},
+			})
+		}
+		f.mu.Unlock()
+		writePage(t, w, r, releases)
+	}))
+	mux.HandleFunc("POST /api/v4/projects/{id}/releases", project(func(w http.ResponseWriter, r *http.Request) {
+		var release map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&release))
+		f.mu.Lock()
+		f.releases = append(f.releases, release)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(t, w, map[string]any{
+			"tag_name": release["tag_name"],
+			"_links":   map[string]any{"self": "https://gitlab.example/group/sub/repo/-/releases/" + release["tag_name"].(string)},
+		})
+	}))
+	return mux
+}
+
+func (f *fakeGitLab) position(sha string) int {
+	for i, s := range f.history {
+		if s == sha {
+			return i
+		}
+	}
+	return -1
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func writePage[T any](t *testing.T, w http.ResponseWriter, r *http.Request, items []T) {
+	t.Helper()
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	require.NoError(t, err)
+	if page < len(items) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	result := []T{}
+	if page > 0 && page <= len(items) {
+		result = items[page-1 : page]
+	}
+	writeJSON(t, w, result)
+}
+
+func newTestClient(t *testing.T, token string) (*Client, *fakeGitLab) {
+	t.Helper()
+	fake := &fakeGitLab{
+		mergeRequests: map[int]mergeRequest{
+			1: {IID: 1, State: "merged", Title: "add foo", MergeCommitSHA: "m1", Labels: []string{"semver:minor"}},
+			2: {IID: 2, State: "merged", Title: "fix bar", SquashCommitSHA: "s2", Labels: []string{"semver:patch"}},
+			3: {IID: 3, State: "opened", Title: "wip"},
+			4: {IID: 4, State: "merged", Title: "ff merge"},
+		},
+		commitMRs: map[string][]int{
+			"c1": {1, 3},
+			"c2": {2},
+			"c3": {4},
+		},
+		mrCommits: map[int][]string{1: {"c1", "c0"}},
+		history:   []string{"base", "c0", "c1", "c2", "c3"},
+		packages:  map[string]string{},
+	}
+	fake.mergeRequests[1] = withAuthor(fake.mergeRequests[1], "alice")
+	server := httptest.NewServer(fake.handler(t))
+	t.Cleanup(server.Close)
+	client, err := NewClient(server.URL+"/api/v4/", token, "release-train/test")
+	require.NoError(t, err)
+	return client, fake
+}
+
+func withAuthor(mr mergeRequest, username string) mergeRequest {
+	mr.Author.Username = username
+	mr.WebURL = "https://gitlab.example/" + testProject + "/-/merge_requests/" + strconv.Itoa(mr.IID)
+	return mr
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ListMergedPullsForCommit", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestClient(t, testToken)
+		got, err := client.ListMergedPullsForCommit(t.Context(), "group/sub", "repo", "c1")
+		require.NoError(t, err)
+		require.Equal(t, []github.BasePull{{
+			Number:         1,
+			MergeCommitSha: "m1",
+			Labels:         []string{"semver:minor"},
+			Title:          "add foo",
+			Author:         "alice",
+			URL:            "https://gitlab.example/group/sub/repo/-/merge_requests/1",
+		}}, got)
+
+		got, err = client.ListMergedPullsForCommit(t.Context(), "group/sub", "repo", "c2")
+		require.NoError(t, err)
+		require.Equal(t, "s2", got[0].MergeCommitSha)
+
+		got, err = client.ListMergedPullsForCommit(t.Context(), "group/sub", "repo", "c3")
+		require.NoError(t, err)
+		require.Equal(t, "c3", got[0].MergeCommitSha)
+	})
+
+	t.Run("CompareCommits", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestClient(t, testToken)
+		got, err := client.CompareCommits(t.Context(), "group/sub", "repo", "base", "c2", -1)
+		require.NoError(t, err)
+		require.Equal(t, &github.CommitComparison{AheadBy: 3, Commits: []string{"c0", "c1", "c2"}}, got)
+
+		got, err = client.CompareCommits(t.Context(), "group/sub", "repo", "base", "c2", 2)
+		require.NoError(t, err)
+		require.Equal(t, &github.CommitComparison{AheadBy: 3, Commits: []string{"c0", "c1"}}, got)
+
+		got, err = client.CompareCommits(t.Context(), "group/sub", "repo", "c3", "c1", 0)
+		require.NoError(t, err)
+		require.Equal(t, &github.CommitComparison{BehindBy: 2}, got)
+	})
+
+	t.Run("GetPullRequest", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestClient(t, testToken)
+		got, err := client.GetPullRequest(t.Context(), "group/sub", "repo", 2)
+		require.NoError(t, err)
+		require.Equal(t, &github.BasePull{
+			Number:         2,
+			MergeCommitSha: "s2",
+			Labels:         []string{"semver:patch"},
+			Title:          "fix bar",
+		}, got)
+
+		_, err = client.GetPullRequest(t.Context(), "group/sub", "repo", 99)
+		require.ErrorContains(t, err, "404 Not Found: {\"message\":\"404 Not found\"}")
+	})
+
+	t.Run("GetPullRequestCommits", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestClient(t, testToken)
+		got, err := client.GetPullRequestCommits(t.Context(), "group/sub", "repo", 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"c1", "c0"}, got)
+	})
+
+	t.Run("GenerateReleaseNotes", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestClient(t, testToken)
+		got, err := client.GenerateReleaseNotes(t.Context(), "group/sub", "repo", "v1.1.0", "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "**Full Changelog**: https://gitlab.example/group/sub/repo/-/compare/v1.0.0...v1.1.0", got)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		t.Parallel()
+		client, fake := newTestClient(t, testToken)
+		ctx := t.Context()
+		rel, err := client.CreateRelease(ctx, "group/sub", "repo", "v1.1.0", "release notes", false)
+		require.NoError(t, err)
+		asset := filepath.Join(t.TempDir(), "foo.txt")
+		require.NoError(t, os.WriteFile(asset, []byte("foo\n"), 0o600))
+		require.NoError(t, client.UploadAsset(ctx, rel.UploadURL, asset))
+		require.Empty(t, fake.releases)
+		published, err := client.PublishRelease(ctx, "group/sub", "repo", "true", rel.ID)
+		require.NoError(t, err)
+		require.Equal(t, &github.RepoRelease{
+			ID:        rel.ID,
+			UploadURL: rel.UploadURL,
+			URL:       "https://gitlab.example/group/sub/repo/-/releases/v1.1.0",
+		}, published)
+		require.Equal(t, map[string]string{"repo/v1.1.0/foo.txt": "foo\n"}, fake.packages)
+		require.Equal(t, []map[string]any{{
+			"tag_name":    "v1.1.0",
+			"name":        "v1.1.0",
+			"description": "release notes",
+			"assets": map[string]any{"links": []any{map[string]any{
+				"name":      "foo.txt",
+				"url":       rel.UploadURL + "/foo.txt",
+				"link_type": "package",
+			}}},
+		}}, fake.releases)
+		_, err = client.PublishRelease(ctx, "group/sub", "repo", "", rel.ID)
+		require.ErrorContains(t, err, "unknown release")
+
+		releases, err := client.ListReleases(ctx, "group/sub", "repo")
+		require.NoError(t, err)
+		require.Equal(t, []github.RepoRelease{{
+			Tag: "v1.1.0",
+			URL: "https://gitlab.example/group/sub/repo/-/releases/v1.1.0",
+		}}, releases)
+	})
+
+	t.Run("prefixed tag", func(t *testing.T) {
+		t.Parallel()
+		client, fake := newTestClient(t, testToken)
+		ctx := t.Context()
+		rel, err := client.CreateRelease(ctx, "group/sub", "repo", "tools/v1.2.0", "release notes", false)
+		require.NoError(t, err)
+		asset := filepath.Join(t.TempDir(), "foo.txt")
+		require.NoError(t, os.WriteFile(asset, []byte("foo\n"), 0o600))
+		require.NoError(t, client.UploadAsset(ctx, rel.UploadURL, asset))
+		require.Equal(t, map[string]string{"repo/tools-v1.2.0/foo.txt": "foo\n"}, fake.packages)
+	})
+
+	t.Run("deleted release isn't published", func(t *testing.T) {
+		t.Parallel()
+		client, fake := newTestClient(t, testToken)
+		ctx := t.Context()
+		rel, err := client.CreateRelease(ctx, "group/sub", "repo", "v1.1.0", "release notes", false)
+		require.NoError(t, err)
+		require.NoError(t, client.DeleteRelease(ctx, "group/sub", "repo", rel.ID))
+		_, err = client.PublishRelease(ctx, "group/sub", "repo", "", rel.ID)
+		require.ErrorContains(t, err, "unknown release")
+		require.Empty(t, fake.releases)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestClient(t, "wrong")
+		_, err := client.ListMergedPullsForCommit(t.Context(), "group/sub", "repo", "c1")
+		require.ErrorContains(t, err, "401 Unauthorized")
+	})
+}
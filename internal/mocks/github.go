@@ -144,12 +144,28 @@ func (mr *MockGithubClientMockRecorder) ListMergedPullsForCommit(ctx, owner, rep
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMergedPullsForCommit", reflect.TypeOf((*MockGithubClient)(nil).ListMergedPullsForCommit), ctx, owner, repo, sha)
 }
 
+// ListReleases mocks base method.
+func (m *MockGithubClient) ListReleases(ctx context.Context, owner, repo string) ([]github.RepoRelease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReleases", ctx, owner, repo)
+	ret0, _ := ret[0].([]github.RepoRelease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReleases indicates an expected call of ListReleases.
+func (mr *MockGithubClientMockRecorder) ListReleases(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleases", reflect.TypeOf((*MockGithubClient)(nil).ListReleases), ctx, owner, repo)
+}
+
 // PublishRelease mocks base method.
-func (m *MockGithubClient) PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) error {
+func (m *MockGithubClient) PublishRelease(ctx context.Context, owner, repo, makeLatest string, id int64) (*github.RepoRelease, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PublishRelease", ctx, owner, repo, makeLatest, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*github.RepoRelease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // PublishRelease indicates an expected call of PublishRelease.
@@ -171,3 +187,234 @@ func (mr *MockGithubClientMockRecorder) UploadAsset(ctx, uploadURL, filename any
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadAsset", reflect.TypeOf((*MockGithubClient)(nil).UploadAsset), ctx, uploadURL, filename)
 }
+
+// MockBatchPullLister is a mock of BatchPullLister interface.
+type MockBatchPullLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchPullListerMockRecorder
+	isgomock struct{}
+}
+
+// MockBatchPullListerMockRecorder is the mock recorder for MockBatchPullLister.
+type MockBatchPullListerMockRecorder struct {
+	mock *MockBatchPullLister
+}
+
+// NewMockBatchPullLister creates a new mock instance.
+func NewMockBatchPullLister(ctrl *gomock.Controller) *MockBatchPullLister {
+	mock := &MockBatchPullLister{ctrl: ctrl}
+	mock.recorder = &MockBatchPullListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchPullLister) EXPECT() *MockBatchPullListerMockRecorder {
+	return m.recorder
+}
+
+// ListMergedPullsForCommits mocks base method.
+func (m *MockBatchPullLister) ListMergedPullsForCommits(ctx context.Context, owner, repo string, shas []string) (map[string][]github.BasePull, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMergedPullsForCommits", ctx, owner, repo, shas)
+	ret0, _ := ret[0].(map[string][]github.BasePull)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMergedPullsForCommits indicates an expected call of ListMergedPullsForCommits.
+func (mr *MockBatchPullListerMockRecorder) ListMergedPullsForCommits(ctx, owner, repo, shas any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMergedPullsForCommits", reflect.TypeOf((*MockBatchPullLister)(nil).ListMergedPullsForCommits), ctx, owner, repo, shas)
+}
+
+// MockReleasePRClient is a mock of ReleasePRClient interface.
+type MockReleasePRClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockReleasePRClientMockRecorder
+	isgomock struct{}
+}
+
+// MockReleasePRClientMockRecorder is the mock recorder for MockReleasePRClient.
+type MockReleasePRClientMockRecorder struct {
+	mock *MockReleasePRClient
+}
+
+// NewMockReleasePRClient creates a new mock instance.
+func NewMockReleasePRClient(ctrl *gomock.Controller) *MockReleasePRClient {
+	mock := &MockReleasePRClient{ctrl: ctrl}
+	mock.recorder = &MockReleasePRClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReleasePRClient) EXPECT() *MockReleasePRClientMockRecorder {
+	return m.recorder
+}
+
+// CreateCommit mocks base method.
+func (m *MockReleasePRClient) CreateCommit(ctx context.Context, owner, repo string, commit *github.NewCommit) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommit", ctx, owner, repo, commit)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCommit indicates an expected call of CreateCommit.
+func (mr *MockReleasePRClientMockRecorder) CreateCommit(ctx, owner, repo, commit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommit", reflect.TypeOf((*MockReleasePRClient)(nil).CreateCommit), ctx, owner, repo, commit)
+}
+
+// CreatePullRequest mocks base method.
+func (m *MockReleasePRClient) CreatePullRequest(ctx context.Context, owner, repo string, pull *github.NewPull) (*github.BasePull, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePullRequest", ctx, owner, repo, pull)
+	ret0, _ := ret[0].(*github.BasePull)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePullRequest indicates an expected call of CreatePullRequest.
+func (mr *MockReleasePRClientMockRecorder) CreatePullRequest(ctx, owner, repo, pull any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePullRequest", reflect.TypeOf((*MockReleasePRClient)(nil).CreatePullRequest), ctx, owner, repo, pull)
+}
+
+// FindOpenPullRequest mocks base method.
+func (m *MockReleasePRClient) FindOpenPullRequest(ctx context.Context, owner, repo, head, base string) (*github.BasePull, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOpenPullRequest", ctx, owner, repo, head, base)
+	ret0, _ := ret[0].(*github.BasePull)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOpenPullRequest indicates an expected call of FindOpenPullRequest.
+func (mr *MockReleasePRClientMockRecorder) FindOpenPullRequest(ctx, owner, repo, head, base any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOpenPullRequest", reflect.TypeOf((*MockReleasePRClient)(nil).FindOpenPullRequest), ctx, owner, repo, head, base)
+}
+
+// UpdateBranch mocks base method.
+func (m *MockReleasePRClient) UpdateBranch(ctx context.Context, owner, repo, branch, sha string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBranch", ctx, owner, repo, branch, sha)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBranch indicates an expected call of UpdateBranch.
+func (mr *MockReleasePRClientMockRecorder) UpdateBranch(ctx, owner, repo, branch, sha any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBranch", reflect.TypeOf((*MockReleasePRClient)(nil).UpdateBranch), ctx, owner, repo, branch, sha)
+}
+
+// UpdatePullRequest mocks base method.
+func (m *MockReleasePRClient) UpdatePullRequest(ctx context.Context, owner, repo string, number int, title, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePullRequest", ctx, owner, repo, number, title, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePullRequest indicates an expected call of UpdatePullRequest.
+func (mr *MockReleasePRClientMockRecorder) UpdatePullRequest(ctx, owner, repo, number, title, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePullRequest", reflect.TypeOf((*MockReleasePRClient)(nil).UpdatePullRequest), ctx, owner, repo, number, title, body)
+}
+
+// MockGitDataClient is a mock of GitDataClient interface.
+type MockGitDataClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockGitDataClientMockRecorder
+	isgomock struct{}
+}
+
+// MockGitDataClientMockRecorder is the mock recorder for MockGitDataClient.
+type MockGitDataClientMockRecorder struct {
+	mock *MockGitDataClient
+}
+
+// NewMockGitDataClient creates a new mock instance.
+func NewMockGitDataClient(ctrl *gomock.Controller) *MockGitDataClient {
+	mock := &MockGitDataClient{ctrl: ctrl}
+	mock.recorder = &MockGitDataClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGitDataClient) EXPECT() *MockGitDataClientMockRecorder {
+	return m.recorder
+}
+
+// CreateCommit mocks base method.
+func (m *MockGitDataClient) CreateCommit(ctx context.Context, owner, repo string, commit *github.NewCommit) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommit", ctx, owner, repo, commit)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCommit indicates an expected call of CreateCommit.
+func (mr *MockGitDataClientMockRecorder) CreateCommit(ctx, owner, repo, commit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommit", reflect.TypeOf((*MockGitDataClient)(nil).CreateCommit), ctx, owner, repo, commit)
+}
+
+// CreateTag mocks base method.
+func (m *MockGitDataClient) CreateTag(ctx context.Context, owner, repo string, tag *github.NewTag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTag", ctx, owner, repo, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTag indicates an expected call of CreateTag.
+func (mr *MockGitDataClientMockRecorder) CreateTag(ctx, owner, repo, tag any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTag", reflect.TypeOf((*MockGitDataClient)(nil).CreateTag), ctx, owner, repo, tag)
+}
+
+// DeleteTag mocks base method.
+func (m *MockGitDataClient) DeleteTag(ctx context.Context, owner, repo, tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", ctx, owner, repo, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockGitDataClientMockRecorder) DeleteTag(ctx, owner, repo, tag any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockGitDataClient)(nil).DeleteTag), ctx, owner, repo, tag)
+}
+
+// FastForwardBranch mocks base method.
+func (m *MockGitDataClient) FastForwardBranch(ctx context.Context, owner, repo, branch, sha string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FastForwardBranch", ctx, owner, repo, branch, sha)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FastForwardBranch indicates an expected call of FastForwardBranch.
+func (mr *MockGitDataClientMockRecorder) FastForwardBranch(ctx, owner, repo, branch, sha any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FastForwardBranch", reflect.TypeOf((*MockGitDataClient)(nil).FastForwardBranch), ctx, owner, repo, branch, sha)
+}
+
+// MoveTag mocks base method.
+func (m *MockGitDataClient) MoveTag(ctx context.Context, owner, repo, tag, sha string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveTag", ctx, owner, repo, tag, sha)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveTag indicates an expected call of MoveTag.
+func (mr *MockGitDataClientMockRecorder) MoveTag(ctx, owner, repo, tag, sha any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveTag", reflect.TypeOf((*MockGitDataClient)(nil).MoveTag), ctx, owner, repo, tag, sha)
+}
@@ -12,6 +12,9 @@ const (
 	labelBreaking   = "semver:breaking"
 	labelStable     = "semver:stable"
 	labelPrerelease = "semver:prerelease"
+
+	// labelReleasePR marks the pull requests opened by --release-pr. Merging one releases its version.
+	labelReleasePR = "release-train:release-pr"
 )
 
 func labelLevel(label string) (changeLevel, bool) {
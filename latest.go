@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const makeLatestAuto = "auto"
+
+// makeLatest returns the make_latest value to publish the release with. With --make-latest=auto, the release is
+// latest only when it is stable and no published stable release with the tag prefix has a higher version, so a
+// hotfix released from a maintenance branch doesn't take latest from a newer major version.
+func (o *Runner) makeLatest(ctx context.Context, result *Result) (string, error) {
+	if o.MakeLatest != makeLatestAuto {
+		return o.MakeLatest, nil
+	}
+	version := result.ReleaseVersion
+	if version.Prerelease() != "" {
+		slog.Info("not marking the release as latest because it is a prerelease", slog.String("tag", result.ReleaseTag))
+		return "false", nil
+	}
+	releases, err := o.GithubClient.ListReleases(ctx, o.repoOwner(), o.repoName())
+	if err != nil {
+		return "", fmt.Errorf("listing releases for --make-latest=auto: %w", err)
+	}
+	for _, rel := range releases {
+		if rel.Draft || rel.Prerelease || !strings.HasPrefix(rel.Tag, o.TagPrefix) {
+			continue
+		}
+		ver, e := semver.StrictNewVersion(strings.TrimPrefix(rel.Tag, o.TagPrefix))
+		if e != nil || ver.Prerelease() != "" || !ver.GreaterThan(version) {
+			continue
+		}
+		slog.Info("not marking the release as latest because a higher stable release exists",
+			slog.String("tag", result.ReleaseTag), slog.String("higher", rel.Tag))
+		return "false", nil
+	}
+	slog.Info("marking the release as latest because it is the highest stable release", slog.String("tag", result.ReleaseTag))
+	return "true", nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
+)
+
+func TestRunner_makeLatest(t *testing.T) {
+	t.Parallel()
+	server := githubtest.NewServer("token")
+	t.Cleanup(server.Close)
+	repo := server.AddRepo("orgName", "repoName")
+	server.AddRelease(repo, githubtest.Release{TagName: "v1.4.0"})
+	server.AddRelease(repo, githubtest.Release{TagName: "v2.0.0"})
+	server.AddRelease(repo, githubtest.Release{TagName: "v2.1.0-rc.1", Prerelease: true})
+	server.AddRelease(repo, githubtest.Release{TagName: "v2.2.0", Prerelease: true})
+	server.AddRelease(repo, githubtest.Release{TagName: "v3.0.0", Draft: true})
+	server.AddRelease(repo, githubtest.Release{TagName: "foo/v9.0.0"})
+	client, err := github.NewClient(server.APIURL, "token", "release-train/test")
+	require.NoError(t, err)
+
+	for _, td := range []struct {
+		makeLatest, version, want string
+	}{
+		{makeLatest: "auto", version: "1.4.1", want: "false"},
+		{makeLatest: "auto", version: "2.0.1", want: "true"},
+		{makeLatest: "auto", version: "2.1.0", want: "true"},
+		{makeLatest: "auto", version: "4.0.0-rc.1", want: "false"},
+		{makeLatest: "legacy", version: "1.4.1", want: "legacy"},
+		{makeLatest: "true", version: "1.4.1", want: "true"},
+	} {
+		runner := &Runner{
+			Repo:         "orgName/repoName",
+			TagPrefix:    "v",
+			GithubClient: client,
+			MakeLatest:   td.makeLatest,
+		}
+		got, err := runner.makeLatest(t.Context(), &Result{
+			ReleaseTag:     "v" + td.version,
+			ReleaseVersion: semver.MustParse(td.version),
+		})
+		require.NoError(t, err)
+		require.Equal(t, td.want, got, "--make-latest=%s for %s", td.makeLatest, td.version)
+	}
+
+	runner := &Runner{Repo: "orgName/missing", TagPrefix: "v", GithubClient: client, MakeLatest: "auto"}
+	_, err = runner.makeLatest(t.Context(), &Result{ReleaseTag: "v1.0.0", ReleaseVersion: semver.MustParse("1.0.0")})
+	require.ErrorContains(t, err, "listing releases for --make-latest=auto")
+}
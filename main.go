@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,12 +9,16 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"text/template"
 
 	"github.com/alecthomas/kong"
 	"github.com/sethvargo/go-githubactions"
 	"github.com/willabides/actionslog"
 	"github.com/willabides/actionslog/human"
+	"github.com/willabides/release-train/v3/internal/gitea"
 	"github.com/willabides/release-train/v3/internal/github"
+	"github.com/willabides/release-train/v3/internal/gitlab"
 	"gopkg.in/yaml.v3"
 )
 
@@ -33,20 +38,47 @@ func helpVars() kong.Vars {
 		"label_help":            `PR label alias in the form of "<alias>=<label>" where <label> is a canonical label.`,
 		"output_format_help":    `Output either json our GitHub action output.`,
 		"debug_help":            `Enable debug logging.`,
+		"plan_help":             `Show what a release would do without changing anything. The pre-tag hook isn't run.`,
+		"plan_format_help":      `Output either human-readable text or json.`,
+		"next_cmd_help":         `Show the next version without running the pre-tag hook or creating anything.`,
+		"release_cmd_help":      `Tag and publish the next release. Same as --create-release.`,
+		"tag_only_help":         `Create and push the tag without creating a release. Same as --create-tag.`,
+		"check_pr_cmd_help":     `Show the release as if the given PR had already been merged. Same as --check-pr.`,
+		"pr_number_help":        `The PR number.`,
+		"prev_tag_cmd_help":     `Show the tag of the previous release.`,
+		"stable_only_help":      `Skip prerelease tags.`,
+		"notes_cmd_help":        `Show the release notes for the next release. Notes written by the pre-tag hook aren't included.`,
+		"cache_dir_help":        `Directory to cache PR lookups in between runs. Restore it with actions/cache to make fewer API calls. Only used with GitHub.`,
+		"label_cache_help":      `File mapping PR numbers to their labels for use with --git-only. Keys are PR numbers and values are lists of labels.`,
+		"release_notes_help":    `Where release notes come from. "github" uses GitHub's generated notes. "builtin" lists the release's PRs grouped by change level.`,
+		"notes_template_help":   `Go text/template file for rendering builtin release notes and changelog sections.`,
+		"changelog_help":        `Add a section for the release to this Keep a Changelog style file and commit it onto the release target. The path is relative to the checkout directory. The section is rendered like builtin release notes.`,
+		"release_pr_help":       `Instead of tagging, open or update a release PR with the release's file changes such as --changelog. Merging the release PR tags and releases its version. Only works with GitHub.`,
+		"pr_branch_help":        `The branch for the release PR. It is force-pushed on every update.`,
+		"config_help":           `Config file to read options from. Defaults to .release-train.yaml in the checkout directory when it exists.`,
 		"draft_help":            `Leave the release as a draft.`,
 		"tempdir_help":          `The prefix to use with mktemp to create a temporary directory.`,
 		"pushremote_help":       `The remote to push tags to.`,
 		"repo_help":             `GitHub repository in the form of owner/repo.`,
 		"github_token_help":     "The GitHub token to use for authentication. Must have `contents: write` permission if creating a release or tag.",
 		"github_api_url_help":   `GitHub API URL.`,
+		"github_app_id_help":    `Authenticate as this GitHub App instead of with --github-token. Needs --github-app-key.`,
+		"app_install_id_help":   `The installation of the GitHub App to authenticate as. Defaults to the app's installation on --repo.`,
+		"forge_help":            `The kind of forge hosting the repository. "auto" guesses from the push remote's host: GitLab for hosts with a "gitlab" label, Gitea for codeberg.org and hosts with a "gitea" or "forgejo" label, and GitHub otherwise. GitLab merge requests are used like pull requests.`,
+		"gitlab_api_url_help":   `GitLab API URL.`,
+		"gitlab_token_help":     "The GitLab token to use for authentication. Must have the `api` scope if creating a release.",
+		"gitea_api_url_help":    "Gitea or Forgejo API URL. Defaults to `https://<host>/api/v1` using the push remote's host.",
+		"gitea_token_help":      "The Gitea or Forgejo token to use for authentication. Must have `write:repository` scope if creating a release.",
 
 		"check_pr_help": `
 Operates as if the given PR has already been merged. Useful for making sure the PR is properly labeled.
 Skips tag and release.
 `,
 		"make_latest_help": `
-Mark the release as "latest" on GitHub. Can be set to "true", "false" or "legacy". See 
-https://docs.github.com/en/rest/releases/releases#update-a-release  for details.`,
+Mark the release as "latest" on GitHub. Can be set to "true", "false", "legacy" or "auto". See 
+https://docs.github.com/en/rest/releases/releases#update-a-release  for details. "auto" lists the repository's
+releases and marks the release as latest only when it is stable and no published stable release with the tag prefix
+has a higher version, so hotfixes for older major versions don't become latest.`,
 
 		"pre_tag_hook_help": `
 Command to run before tagging the release. You may abort the release by exiting with a non-zero exit code. Exit code 0
@@ -89,7 +121,8 @@ Environment variables available to the hook:
     RELEASE_NOTES_FILE
       A file path where you can write custom release notes.
       When nothing is written to this file, release-train
-      will use GitHub's default release notes.
+      will use GitHub's default release notes or its builtin
+      notes when --release-notes=builtin.
 
     RELEASE_TARGET
       A file path where you can write an alternate git ref
@@ -100,11 +133,42 @@ Environment variables available to the hook:
       files in this directory will be uploaded as release
       assets.
 
+    RELEASE_COMPONENT
+      The name of the component being released when
+      --component is set. Empty otherwise.
+
+    RELEASE_PULLS_FILE
+      A file with a JSON array of the PRs in the release.
+      See the pulls output for their fields.
+
 In addition to the above environment variables, all variables from release-train's environment are available to the
 hook.
 
 When the hook creates a tag named $RELEASE_TAG, it will be used as the release target instead of either HEAD or the
 value written to $RELEASE_TARGET.
+`,
+
+		"post_tag_hook_help": `
+Command to run after the release tag is pushed and before the release is created. It gets the same environment
+variables as the pre-tag hook, and the tag exists in the checkout, so it can build things that need the tagged commit
+such as "git describe" output. It may still write to ASSETS_DIR and RELEASE_NOTES_FILE. When it exits non-zero, the
+tag is deleted and the release is aborted.
+`,
+
+		"post_release_help": `
+Command to run after the release is published. It gets the same environment variables as the pre-tag hook plus:
+
+    RELEASE_ID
+      The ID of the release.
+
+    RELEASE_URL
+      The URL of the release's web page.
+
+    RELEASE_UPLOAD_URL
+      The URL for uploading more release assets.
+
+The hook doesn't run for draft releases. The release can't be undone once it is published, so a failing hook fails
+the run but leaves the release in place.
 `,
 
 		"pre_release_hook_help": `
@@ -114,11 +178,101 @@ value written to $RELEASE_TARGET.
 		"v0_help": `
 Assert that current major version is 0 and treat breaking changes as minor changes.
 Errors if the major version is not 0.
+`,
+
+		"git_only_help": `
+Determine the change level from local git history instead of the GitHub API. PRs are found from merge and
+squash commit messages, and their labels are read from --label-cache. Commits may also set their change level with
+a trailer such as "Semver: minor". Can't be combined with --check-pr or --create-release.
+`,
+
+		"level_source_help": `
+Where change levels come from. "labels" uses PR labels. "conventional-commits" uses Conventional Commits messages:
+breaking changes are major, "feat" is minor, "fix" is patch and other types are resolved as label aliases or
+default to none. "mixed" uses PR labels when a PR has them and commit messages otherwise.
 `,
 
 		"release_ref_help": `
 Only allow tags and releases to be created from matching refs. Refs can be patterns accepted by git-show-ref.
 If undefined, any branch can be used.
+`,
+
+		"version_file_help": `
+Update the version in a file and commit it onto the release target with the release. The value is KIND:PATH[:ARG]
+where PATH is relative to the checkout directory. KIND is one of:
+"regex" replaces the "version" group of every match of the regular expression ARG.
+"json" sets the string at the dotted key path ARG, which defaults to "version".
+"toml" sets the string at the dotted key ARG, which defaults to "package.version" for Cargo.toml and "project.version"
+for pyproject.toml.
+"go" sets the string constant ARG, which defaults to "Version".
+Except for "regex", versions that had a "v" prefix keep it.
+`,
+
+		"go_module_help": `
+Check that the module path in go.mod works with major releases. Releasing v2 or higher needs the module path to end
+with /v2 or higher, so a major release fails when it doesn't match. go.mod is in the directory named by the tag
+prefix: the root for "v" and DIR for "DIR/v". The check runs on the release target after the pre-tag hook, including
+with --check-pr.
+`,
+
+		"tag_message_help": `
+Create annotated release tags with this message. The value is a Go text/template that gets the same fields as
+--notes-template, such as {{.Tag}}, {{.Version}} and {{.Pulls}}. Tags created by the pre-tag hook are left alone.
+`,
+
+		"sign_tags_help": `
+Sign release tags with "git tag --sign". Signed tags are annotated, and their message is the tag name unless
+--tag-message is set. git must be configured to sign, for example with user.signingKey.
+`,
+
+		"signing_key_help": `
+The key to sign release tags with. It is passed to "git tag --local-user". Needs --sign-tags.
+`,
+
+		"signing_format_help": `
+The signature format for signing release tags: "openpgp", "ssh" or "x509". It sets git's gpg.format. Defaults to git's
+configuration. Needs --sign-tags.
+`,
+
+		"api_push_help": `
+Create the release tag, the release commit and any commits made by the pre-tag hook with GitHub's Git Data API and
+move the release branch with it instead of using git push. The token needs contents write permission, but no push
+credentials are needed, and GitHub signs the commits it creates. Merge commits can't be created this way. Tags keep
+their message but not their signature, so this can't be used with --sign-tags. The commits get new shas on GitHub,
+so the local tag and branch still point at the local commits afterward.
+`,
+
+		"alias_tags_help": `
+Move floating alias tags to every stable release: the major version and the major.minor version such as v3 and v3.2
+for v3.2.1. The tags point to the release's commit and are force-pushed after the release is published, or after it
+is tagged without --create-release. Prereleases and drafts don't move them, and neither do releases older than a
+release the alias already covers.
+`,
+
+		"alias_latest_help": `
+With --alias-tags, also move the "latest" tag to the newest stable release. The tag prefix's directory is kept, so the
+tag for the prefix "foo/v" is "foo/latest".
+`,
+
+		"github_app_key_help": `
+The GitHub App's PEM encoded private key. release-train signs a JSON Web Token with it and exchanges that for an
+installation token, which is replaced before it expires so long runs such as uploading many release assets keep
+working. The installation token is used in place of --github-token, including as GITHUB_TOKEN in hooks.
+`,
+
+		"go_api_check_help": `
+With --check-pr, compare the exported API of the Go module at the PR's merge base and head and fail when the PR's
+level label is lower than the changes need. Incompatible changes need semver:breaking and compatible additions need
+semver:minor. With --v0, incompatible changes only need semver:minor. go.mod is found like --go-module, falling back
+to the checkout directory. Packages named main and internal packages are ignored. PRs without a level label are
+skipped.
+`,
+
+		"component_help": `
+Release a component of a monorepo. The value is NAME:TAG_PREFIX:PATH[,PATH...] where the paths are relative to the
+checkout directory and TAG_PREFIX defaults to NAME/v. When components are set, each one is released separately with
+its own tag prefix in place of --tag-prefix, and only commits that change files in its paths count toward its
+release. --changelog and --version-file paths are relative to the component's first path.
 `,
 	}
 }
@@ -155,22 +309,161 @@ type rootCmd struct {
 	TagPrefix       string            `default:"v" help:"${tag_prefix_help}"`
 	V0              bool              `name:"v0" help:"${v0_help}"`
 	InitialTag      string            `action:"initial-release-tag" help:"${initial_tag_help}" default:"v0.0.0"`
-	MakeLatest      string            `action:"make-latest" default:"legacy" help:"${make_latest_help}" enum:"legacy,true,false"`
+	MakeLatest      string            `action:"make-latest" default:"legacy" help:"${make_latest_help}" enum:"legacy,true,false,auto"`
 	PreTagHook      string            `placeholder:"<command>" help:"${pre_tag_hook_help}"`
 	PreReleaseHook  string            `placeholder:"<command>" help:"${pre_release_hook_help}"`
+	PostTagHook     string            `placeholder:"<command>" help:"${post_tag_hook_help}"`
+	PostReleaseHook string            `placeholder:"<command>" help:"${post_release_help}"`
 	ReleaseRef      []string          `action:"release-refs" placeholder:"<branch>" help:"${release_ref_help}"`
 	PushRemote      string            `action:"-" default:"origin" help:"${pushremote_help}"`
 	Tempdir         string            `help:"${tempdir_help}"`
 	GithubApiUrl    string            `action:"-" help:"${github_api_url_help}" default:"https://api.github.com"`
+	GithubAppId     int64             `help:"${github_app_id_help}"`
+	AppInstallId    int64             `name:"github-app-installation-id" help:"${app_install_id_help}"`
+	GithubAppKey    string            `action:"github-app-key" hidden:"true" env:"GITHUB_APP_KEY" help:"${github_app_key_help}"`
+	Forge           string            `default:"github" help:"${forge_help}" enum:"auto,github,gitlab,gitea"`
+	GitlabApiUrl    string            `action:"-" help:"${gitlab_api_url_help}" default:"https://gitlab.com/api/v4"`
+	GitlabToken     string            `action:"-" hidden:"true" env:"GITLAB_TOKEN" help:"${gitlab_token_help}"`
+	GiteaApiUrl     string            `action:"-" help:"${gitea_api_url_help}"`
+	GiteaToken      string            `action:"-" hidden:"true" env:"GITEA_TOKEN" help:"${gitea_token_help}"`
 	OutputFormat    string            `action:"-" default:"json" help:"${output_format_help}" enum:"json,action"`
 	Debug           bool              `help:"${debug_help}"`
+	Config          string            `placeholder:"<file>" help:"${config_help}"`
+	GitOnly         bool              `help:"${git_only_help}"`
+	LabelCache      string            `placeholder:"<file>" help:"${label_cache_help}"`
+	CacheDir        string            `placeholder:"<dir>" help:"${cache_dir_help}"`
+	LevelSource     string            `default:"labels" help:"${level_source_help}" enum:"labels,conventional-commits,mixed"`
+	ReleaseNotes    string            `default:"github" help:"${release_notes_help}" enum:"github,builtin"`
+	NotesTemplate   string            `placeholder:"<file>" help:"${notes_template_help}"`
+	TagMessage      string            `placeholder:"<template>" help:"${tag_message_help}"`
+	SignTags        bool              `help:"${sign_tags_help}"`
+	SigningKey      string            `placeholder:"<key>" help:"${signing_key_help}"`
+	SigningFormat   string            `placeholder:"<format>" help:"${signing_format_help}"`
+	Changelog       string            `placeholder:"<file>" help:"${changelog_help}"`
+	VersionFile     []string          `action:"version-files" sep:"none" placeholder:"<kind:path[:arg]>" help:"${version_file_help}"`
+	Component       []string          `action:"components" sep:"none" placeholder:"<name:tag-prefix:paths>" help:"${component_help}"`
+	GoModule        bool              `help:"${go_module_help}"`
+	GoApiCheck      bool              `help:"${go_api_check_help}"`
+	ReleasePr       bool              `name:"release-pr" help:"${release_pr_help}"`
+	ReleasePrBranch string            `name:"release-pr-branch" default:"release-train/release" placeholder:"<branch>" help:"${pr_branch_help}"`
+	ApiPush         bool              `help:"${api_push_help}"`
+	AliasTags       bool              `help:"${alias_tags_help}"`
+	AliasLatest     bool              `help:"${alias_latest_help}"`
+
+	Default defaultCmd `cmd:"" default:"1" hidden:""`
+	Next    nextCmd    `cmd:"" help:"${next_cmd_help}"`
+	Release releaseCmd `cmd:"" help:"${release_cmd_help}"`
+	CheckPr checkPRCmd `cmd:"" name:"check-pr" help:"${check_pr_cmd_help}"`
+	PrevTag prevTagCmd `cmd:"" help:"${prev_tag_cmd_help}"`
+	Notes   notesCmd   `cmd:"" help:"${notes_cmd_help}"`
+	Plan    planCmd    `cmd:"" help:"${plan_help}"`
+}
+
+// BeforeResolve loads the config file and registers it as a resolver, so it fills in any flag that wasn't set on the
+// command line or from the environment.
+func (c *rootCmd) BeforeResolve(kongCtx *kong.Context) error {
+	if flagValue(kongCtx, "generate-action") == true {
+		return nil
+	}
+	explicit, _ := flagValue(kongCtx, "config").(string)
+	checkoutDir, _ := flagValue(kongCtx, "checkout-dir").(string)
+	filename, err := findConfigFile(explicit, checkoutDir)
+	if err != nil || filename == "" {
+		return err
+	}
+	cfg, err := loadConfig(kongCtx, filename)
+	if err != nil {
+		return err
+	}
+	kongCtx.AddResolver(cfg)
+	return nil
 }
 
-func (c *rootCmd) GithubClient() (GithubClient, error) {
-	return github.NewClient(c.GithubApiUrl, c.GithubToken, fmt.Sprintf("release-train/%s", version))
+// flagValue returns the value of the named flag as set on the command line or from its default.
+func flagValue(kongCtx *kong.Context, name string) any {
+	for _, flag := range kongCtx.Flags() {
+		if flag.Name == name {
+			return kongCtx.FlagValue(flag)
+		}
+	}
+	return nil
 }
 
-func (c *rootCmd) Run(ctx context.Context, kongCtx *kong.Context) error {
+// GithubClient returns a client for forge. host is the push remote's host. repo is used to find the GitHub App's
+// installation when --github-app-id is set without --github-app-installation-id.
+func (c *rootCmd) GithubClient(repo, forge, host string) (GithubClient, error) {
+	userAgent := fmt.Sprintf("release-train/%s", version)
+	switch forge {
+	case forgeGitlab:
+		return gitlab.NewClient(c.GitlabApiUrl, c.GitlabToken, userAgent)
+	case forgeGitea:
+		return gitea.NewClient(cmp.Or(c.GiteaApiUrl, giteaAPIURL(host)), c.GiteaToken, userAgent)
+	}
+	var client *github.Client
+	var err error
+	if c.GithubAppId != 0 {
+		owner, name, _ := strings.Cut(repo, "/")
+		client, err = github.NewAppClient(c.GithubApiUrl, &github.App{
+			ID:             c.GithubAppId,
+			InstallationID: c.AppInstallId,
+			Owner:          owner,
+			Repo:           name,
+			PrivateKey:     []byte(c.GithubAppKey),
+		}, userAgent)
+	} else {
+		client, err = github.NewClient(c.GithubApiUrl, c.GithubToken, userAgent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.CacheDir != "" {
+		client = client.WithCache(c.CacheDir)
+	}
+	return client, nil
+}
+
+// validateGithubApp checks that the GitHub App flags are complete and only used with GitHub.
+func (c *rootCmd) validateGithubApp(forge string) error {
+	if c.GithubAppId == 0 {
+		if c.GithubAppKey != "" || c.AppInstallId != 0 {
+			return errors.New("--github-app-key and --github-app-installation-id need --github-app-id")
+		}
+		return nil
+	}
+	if c.GithubAppKey == "" {
+		return errors.New("--github-app-id needs --github-app-key")
+	}
+	if forge != forgeGithub {
+		return fmt.Errorf("--github-app-id is only supported with GitHub, not %s", forge)
+	}
+	return nil
+}
+
+// resolveRepo returns the repository and the forge hosting it. The push remote is only required when --repo isn't
+// set or its host is needed for the Gitea API URL. A repository from the push remote keeps its full path on GitLab,
+// where projects can be in subgroups, and is owner/repo elsewhere.
+func (c *rootCmd) resolveRepo(ctx context.Context) (repo, forge, host string, _ error) {
+	repo, forge = c.Repo, c.Forge
+	needHost := forge == forgeAuto || (forge == forgeGitea && c.GiteaApiUrl == "")
+	var remoteRepo string
+	if repo == "" || needHost {
+		var err error
+		remoteRepo, host, err = getGithubRepoFromRemote(ctx, c.CheckoutDir, c.PushRemote)
+		if err != nil && (repo == "" || forge == forgeGitea) {
+			return "", "", "", err
+		}
+	}
+	if forge == forgeAuto {
+		forge = forgeFromHost(host)
+		slog.Debug("detected forge", slog.String("forge", forge), slog.String("host", host))
+	}
+	if forge != forgeGitlab {
+		remoteRepo = ownerRepo(remoteRepo)
+	}
+	return cmp.Or(repo, remoteRepo), forge, host, nil
+}
+
+func (c *rootCmd) setupLogging() {
 	var slogOpts slog.HandlerOptions
 	if c.Debug {
 		slogOpts.Level = slog.LevelDebug
@@ -193,10 +486,6 @@ func (c *rootCmd) Run(ctx context.Context, kongCtx *kong.Context) error {
 		}
 	}
 	slog.SetDefault(slog.New(logHandler))
-	if c.GenerateAction {
-		return c.generateAction(kongCtx)
-	}
-	return c.runRelease(ctx, kongCtx.Stdout, kongCtx.Stderr)
 }
 
 func (c *rootCmd) generateAction(kongCtx *kong.Context) error {
@@ -210,12 +499,53 @@ func (c *rootCmd) generateAction(kongCtx *kong.Context) error {
 	return enc.Encode(got)
 }
 
-func (c *rootCmd) runRelease(ctx context.Context, stdout, stderr io.Writer) (errOut error) {
+func (c *rootCmd) runRelease(ctx context.Context, stdout, stderr io.Writer) error {
 	slog.Debug("starting runRelease")
-	client, err := c.GithubClient()
+	return c.withRunner(ctx, stdout, stderr, func(runner *Runner) error {
+		result, err := runner.run(ctx)
+		if result != nil {
+			// a release that failed after publishing still has outputs
+			err = errors.Join(err, c.writeResult(result))
+		}
+		return err
+	})
+}
+
+// writeResult outputs result according to --output-format.
+func (c *rootCmd) writeResult(result *Result) error {
+	if c.OutputFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	action := githubactions.New()
+	for _, item := range outputItems() {
+		action.SetOutput(item.name, item.value(result))
+	}
+	return nil
+}
+
+// withRunner calls fn with a Runner configured from the flags. The Runner's temp directory is removed after fn
+// returns.
+func (c *rootCmd) withRunner(ctx context.Context, stdout, stderr io.Writer, fn func(*Runner) error) (errOut error) {
+	repo, forge, host, err := c.resolveRepo(ctx)
 	if err != nil {
 		return err
 	}
+	err = c.validateGithubApp(forge)
+	if err != nil {
+		return err
+	}
+	client, err := c.GithubClient(repo, forge, host)
+	if err != nil {
+		return err
+	}
+	// the installation token expires, so hooks get the current one when they run
+	var githubTokenFunc func(context.Context) (string, error)
+	if appClient, ok := client.(*github.Client); ok && c.GithubAppId != 0 {
+		githubTokenFunc = appClient.InstallationToken
+	}
 	tempDir, err := os.MkdirTemp(c.Tempdir, "release-train-*")
 	if err != nil {
 		return err
@@ -228,14 +558,6 @@ func (c *rootCmd) runRelease(ctx context.Context, stdout, stderr io.Writer) (err
 		createTag = true
 	}
 
-	repo := c.Repo
-	if repo == "" {
-		repo, err = getGithubRepoFromRemote(ctx, c.CheckoutDir, c.PushRemote)
-		if err != nil {
-			return err
-		}
-	}
-
 	preTagHook := c.PreTagHook
 	if c.PreReleaseHook != "" {
 		if preTagHook != "" {
@@ -244,10 +566,113 @@ func (c *rootCmd) runRelease(ctx context.Context, stdout, stderr io.Writer) (err
 		preTagHook = c.PreReleaseHook
 	}
 
+	if forge == forgeGitlab && c.Draft {
+		return errors.New("GitLab doesn't support draft releases")
+	}
+
+	if c.GitOnly && c.CreateRelease {
+		return errors.New("cannot specify both --git-only and --create-release")
+	}
+
+	if c.GitOnly && c.ReleasePr {
+		return errors.New("cannot specify both --git-only and --release-pr")
+	}
+
+	if c.GitOnly && c.ApiPush {
+		return errors.New("cannot specify both --git-only and --api-push")
+	}
+
+	if c.ApiPush && forge != forgeGithub {
+		return fmt.Errorf("--api-push is only supported with GitHub, not %s", forge)
+	}
+
+	if c.AliasLatest && !c.AliasTags {
+		return errors.New("--alias-latest needs --alias-tags")
+	}
+
+	if c.SignTags && c.ApiPush {
+		return errors.New("cannot specify both --sign-tags and --api-push because tags created with the API aren't signed")
+	}
+
+	var notesTemplate *template.Template
+	if c.NotesTemplate != "" {
+		if c.ReleaseNotes != releaseNotesBuiltin && c.Changelog == "" {
+			return errors.New("--notes-template requires --release-notes=builtin or --changelog")
+		}
+		var content []byte
+		content, err = os.ReadFile(c.NotesTemplate)
+		if err != nil {
+			return err
+		}
+		notesTemplate, err = parseNotesTemplate(string(content))
+		if err != nil {
+			return fmt.Errorf("invalid notes template %s: %w", c.NotesTemplate, err)
+		}
+	}
+
+	var tagMessage *template.Template
+	if c.TagMessage != "" {
+		tagMessage, err = parseTagMessage(c.TagMessage)
+		if err != nil {
+			return fmt.Errorf("invalid --tag-message: %w", err)
+		}
+	}
+	if !c.SignTags && (c.SigningKey != "" || c.SigningFormat != "") {
+		return errors.New("--signing-key and --signing-format need --sign-tags")
+	}
+	err = validateSigningFormat(c.SigningFormat)
+	if err != nil {
+		return err
+	}
+
+	versionFiles := make([]*versionFile, len(c.VersionFile))
+	for i, spec := range c.VersionFile {
+		versionFiles[i], err = parseVersionFile(spec)
+		if err != nil {
+			return fmt.Errorf("invalid --version-file %q: %w", spec, err)
+		}
+	}
+
+	components := make([]*component, len(c.Component))
+	for i, spec := range c.Component {
+		components[i], err = parseComponent(spec)
+		if err != nil {
+			return fmt.Errorf("invalid --component %q: %w", spec, err)
+		}
+	}
+	err = validateComponents(components)
+	if err != nil {
+		return err
+	}
+	if len(components) > 0 && c.ReleasePr {
+		return errors.New("cannot specify both --component and --release-pr")
+	}
+
+	if c.GoModule {
+		prefixes := []string{c.TagPrefix}
+		if len(components) > 0 {
+			prefixes = prefixes[:0]
+			for _, comp := range components {
+				prefixes = append(prefixes, comp.TagPrefix)
+			}
+		}
+		for _, prefix := range prefixes {
+			_, err = goModuleDir(prefix)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.GoApiCheck && c.LevelSource == levelSourceConventional {
+		return errors.New("--go-api-check compares the API with PR labels, so it can't be used with --level-source=conventional-commits")
+	}
+
 	runner := &Runner{
 		CheckoutDir:     c.CheckoutDir,
 		Ref:             c.Ref,
 		GithubToken:     c.GithubToken,
+		GithubTokenFunc: githubTokenFunc,
 		CreateTag:       createTag,
 		CreateRelease:   c.CreateRelease,
 		Draft:           c.Draft,
@@ -255,11 +680,16 @@ func (c *rootCmd) runRelease(ctx context.Context, stdout, stderr io.Writer) (err
 		TagPrefix:       c.TagPrefix,
 		InitialTag:      c.InitialTag,
 		PreTagHook:      preTagHook,
+		PostTagHook:     c.PostTagHook,
+		PostReleaseHook: c.PostReleaseHook,
 		Repo:            repo,
 		PushRemote:      c.PushRemote,
 		TempDir:         tempDir,
 		ReleaseRefs:     c.ReleaseRef,
 		LabelAliases:    c.Label,
+		GitOnly:         c.GitOnly,
+		LabelCache:      c.LabelCache,
+		LevelSource:     c.LevelSource,
 		CheckPR:         c.CheckPR,
 		GithubClient:    client,
 		Stdout:          stdout,
@@ -267,22 +697,23 @@ func (c *rootCmd) runRelease(ctx context.Context, stdout, stderr io.Writer) (err
 		ForcePrerelease: c.ForcePrerelease,
 		ForceStable:     c.ForceStable,
 		MakeLatest:      c.MakeLatest,
+		ReleaseNotes:    c.ReleaseNotes,
+		NotesTemplate:   notesTemplate,
+		TagMessage:      tagMessage,
+		SignTags:        c.SignTags,
+		SigningKey:      c.SigningKey,
+		SigningFormat:   c.SigningFormat,
+		Changelog:       c.Changelog,
+		VersionFiles:    versionFiles,
+		Components:      components,
+		GoModule:        c.GoModule,
+		GoAPICheck:      c.GoApiCheck,
+		ReleasePR:       c.ReleasePr,
+		ReleasePRBranch: c.ReleasePrBranch,
+		APIPush:         c.ApiPush,
+		AliasTags:       c.AliasTags,
+		AliasLatest:     c.AliasLatest,
 	}
 
-	result, err := runner.run(ctx)
-	if err != nil {
-		return err
-	}
-
-	if c.OutputFormat == "json" {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(result)
-	}
-
-	action := githubactions.New()
-	for _, item := range outputItems() {
-		action.SetOutput(item.name, item.value(result))
-	}
-	return nil
+	return fn(runner)
 }
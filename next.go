@@ -1,10 +1,12 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
 
@@ -22,6 +24,11 @@ func getCommitPRs(
 	if err != nil {
 		return nil, err
 	}
+	return commitPulls(opts, ghResult, checkAncestor)
+}
+
+// commitPulls converts the pulls for a commit, skipping any whose merge commit isn't an ancestor of the head.
+func commitPulls(opts *getNextOptions, ghResult []github.BasePull, checkAncestor func(string) bool) ([]ghPull, error) {
 	result := make([]ghPull, 0, len(ghResult))
 	for _, r := range ghResult {
 		if !checkAncestor(r.MergeCommitSha) {
@@ -31,18 +38,42 @@ func getCommitPRs(
 		if e != nil {
 			return nil, e
 		}
+		p.Title, p.Author, p.URL = r.Title, r.Author, r.URL
 		result = append(result, *p)
 	}
 	return result, nil
 }
 
+// compareCommits lists the commits between opts.Base and opts.Head from the local checkout and looks up their pulls
+// with the API. The API's compare endpoint caps the commits it returns, so it is only used to check that the API
+// agrees with local history.
 func compareCommits(ctx context.Context, opts *getNextOptions) ([]gitCommit, error) {
-	var result []gitCommit
-	comp, err := opts.GithubClient.CompareCommits(ctx, opts.owner(), opts.repo(), opts.Base, opts.Head, -1)
+	shas, err := listCommitShas(ctx, opts.RepoDir, opts.Base, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+	comp, err := opts.GithubClient.CompareCommits(ctx, opts.owner(), opts.repo(), opts.Base, opts.Head, 0)
+	if err != nil {
+		return nil, err
+	}
+	if comp.AheadBy != len(shas) {
+		slog.Warn(
+			"local history doesn't match the API. Make sure the checkout is up to date.",
+			slog.String("base", opts.Base),
+			slog.String("head", opts.Head),
+			slog.Int("local_commits", len(shas)),
+			slog.Int("api_commits", comp.AheadBy),
+		)
+	}
+	// commits in the comparison are all reachable from the head
+	ancestorLookup := make(map[string]bool, len(shas))
+	for _, sha := range shas {
+		ancestorLookup[sha] = true
+	}
+	shas, err = filterPaths(ctx, opts, shas)
 	if err != nil {
 		return nil, err
 	}
-	ancestorLookup := map[string]bool{}
 	var ancestorMux sync.RWMutex
 	var ancestorErr error
 	checkAncestor := func(sha string) bool {
@@ -69,41 +100,79 @@ func compareCommits(ctx context.Context, opts *getNextOptions) ([]gitCommit, err
 		ancestorLookup[sha] = ancestorComp.BehindBy == 0
 		return ancestorLookup[sha]
 	}
-	result = make([]gitCommit, len(comp.Commits))
-	var wg sync.WaitGroup
-	var errLock sync.Mutex
-	for i := range comp.Commits {
-		commitSha := comp.Commits[i]
-		result[i].Sha = commitSha
-		wg.Add(1)
-		go func(idx int) {
-			var e error
-			result[idx].Pulls, e = getCommitPRs(ctx, opts, commitSha, checkAncestor)
-			errLock.Lock()
-			err = errors.Join(err, e)
-			errLock.Unlock()
-			wg.Done()
-		}(i)
-	}
-	wg.Wait()
+	result := make([]gitCommit, len(shas))
+	for i := range shas {
+		result[i].Sha = shas[i]
+	}
+	batched, err := batchCommitPulls(ctx, opts, result, checkAncestor)
 	if err != nil {
 		return nil, err
 	}
+	if !batched {
+		var wg sync.WaitGroup
+		var errLock sync.Mutex
+		for i := range result {
+			wg.Add(1)
+			go func(idx int) {
+				var e error
+				result[idx].Pulls, e = getCommitPRs(ctx, opts, result[idx].Sha, checkAncestor)
+				errLock.Lock()
+				err = errors.Join(err, e)
+				errLock.Unlock()
+				wg.Done()
+			}(i)
+		}
+		wg.Wait()
+		if err != nil {
+			return nil, err
+		}
+	}
 	if ancestorErr != nil {
 		return nil, ancestorErr
 	}
-	for i := range result {
-		err = result[i].validate()
+	return result, nil
+}
+
+// batchCommitPulls sets the pulls for commits in batches when the client supports it. It returns false when commits
+// need to be looked up one at a time instead.
+func batchCommitPulls(
+	ctx context.Context,
+	opts *getNextOptions,
+	commits []gitCommit,
+	checkAncestor func(string) bool,
+) (bool, error) {
+	lister, ok := opts.GithubClient.(BatchPullLister)
+	if !ok || len(commits) == 0 {
+		return false, nil
+	}
+	shas := make([]string, len(commits))
+	for i := range commits {
+		shas[i] = commits[i].Sha
+	}
+	basePulls, err := lister.ListMergedPullsForCommits(ctx, opts.owner(), opts.repo(), shas)
+	if errors.Is(err, github.ErrGraphQLUnsupported) {
+		slog.Debug("falling back to looking up pulls one commit at a time", slog.String("reason", err.Error()))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for i := range commits {
+		commits[i].Pulls, err = commitPulls(opts, basePulls[commits[i].Sha], checkAncestor)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 	}
-	return result, nil
+	return true, nil
 }
 
 type getNextOptions struct {
 	GithubClient    GithubClient
 	Repo            string
+	RepoDir         string
+	GitOnly         bool
+	LabelCache      string
+	LevelSource     string
 	PrevVersion     string
 	Base            string
 	Head            string
@@ -113,6 +182,8 @@ type getNextOptions struct {
 	LabelAliases    map[string]string
 	ForcePrerelease bool
 	ForceStable     bool
+	// Paths limits the commits to those that change files in these paths when it isn't empty.
+	Paths []string
 }
 
 func (o *getNextOptions) repo() string {
@@ -120,17 +191,27 @@ func (o *getNextOptions) repo() string {
 	return repo
 }
 
+func (o *getNextOptions) levelSource() string {
+	return cmp.Or(o.LevelSource, levelSourceLabels)
+}
+
 func (o *getNextOptions) owner() string {
 	owner, _, _ := strings.Cut(o.Repo, "/")
 	return owner
 }
 
 func getNext(ctx context.Context, opts *getNextOptions) (*versionChange, error) {
+	change, _, err := getNextWithPulls(ctx, opts)
+	return change, err
+}
+
+// getNextWithPulls is getNext that also returns the pulls that make up the change.
+func getNextWithPulls(ctx context.Context, opts *getNextOptions) (*versionChange, ghPulls, error) {
 	if opts == nil {
 		opts = &getNextOptions{}
 	}
 	if opts.ForceStable && opts.ForcePrerelease {
-		return nil, errors.New("cannot specify both --force-stable and --force-prerelease")
+		return nil, nil, errors.New("cannot specify both --force-stable and --force-prerelease")
 	}
 	slog.Debug(
 		"starting GetNext",
@@ -152,28 +233,67 @@ func getNext(ctx context.Context, opts *getNextOptions) (*versionChange, error)
 		prevVersion = opts.Base
 	}
 	if minBump > maxBump {
-		return nil, errors.New("minBump must be less than or equal to maxBump")
+		return nil, nil, errors.New("minBump must be less than or equal to maxBump")
 	}
 	prev, err := semver.NewVersion(prevVersion)
 	if err != nil {
-		return nil, fmt.Errorf("invalid previous version %q: %w", prevVersion, err)
-	}
-	if opts.repo() == "" {
-		return nil, errors.New("repo must be in the form owner/name")
+		return nil, nil, fmt.Errorf("invalid previous version %q: %w", prevVersion, err)
 	}
-	commits, err := compareCommits(ctx, opts)
+	commits, err := listCommits(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	slog.Debug("found commits", slog.Any("commits", commits))
+	if opts.levelSource() == levelSourceLabels {
+		err = gitCommits(commits).validate()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 	if opts.CheckPR != 0 {
-		commits, err = includePullInResults(ctx, opts, commits)
+		if opts.GitOnly {
+			return nil, nil, errors.New("cannot check a PR without the GitHub API")
+		}
+		if opts.levelSource() == levelSourceConventional {
+			commits, err = includePullCommits(ctx, opts, commits)
+		} else {
+			commits, err = includePullInResults(ctx, opts, commits)
+		}
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		slog.Debug("found commits after including PR", slog.Any("commits", commits))
 	}
-	return calculateVersionChange(*prev, minBump, maxBump, commits, opts.ForcePrerelease, opts.ForceStable)
+	if opts.levelSource() != levelSourceLabels {
+		commits, err = applyLevelSource(ctx, opts, commits)
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Debug("found commits after applying level source", slog.Any("commits", commits))
+		err = gitCommits(commits).validate()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	change, err := calculateVersionChange(*prev, minBump, maxBump, commits, opts.ForcePrerelease, opts.ForceStable)
+	if err != nil {
+		return nil, nil, err
+	}
+	return change, gitCommits(commits).pulls(), nil
+}
+
+// listCommits returns the commits between opts.Base and opts.Head along with the pulls that introduced them.
+func listCommits(ctx context.Context, opts *getNextOptions) ([]gitCommit, error) {
+	switch {
+	case opts.levelSource() == levelSourceConventional:
+		return localGitCommits(ctx, opts)
+	case opts.GitOnly:
+		return gitOnlyCommits(ctx, opts)
+	case opts.repo() == "":
+		return nil, errors.New("repo must be in the form owner/name")
+	default:
+		return compareCommits(ctx, opts)
+	}
 }
 
 func includePullInResults(ctx context.Context, opts *getNextOptions, commits []gitCommit) ([]gitCommit, error) {
@@ -185,6 +305,7 @@ func includePullInResults(ctx context.Context, opts *getNextOptions, commits []g
 	if err != nil {
 		return nil, err
 	}
+	pull.Title, pull.Author, pull.URL = base.Title, base.Author, base.URL
 	pullCommits, err := opts.GithubClient.GetPullRequestCommits(ctx, opts.owner(), opts.repo(), opts.CheckPR)
 	if err != nil {
 		return nil, err
@@ -202,3 +323,38 @@ func includePullInResults(ctx context.Context, opts *getNextOptions, commits []g
 	}
 	return result, nil
 }
+
+// includePullCommits adds the commits of opts.CheckPR that aren't already in commits. Conventional Commits levels come
+// from the messages of the PR's own commits, so they need to be in the local checkout.
+func includePullCommits(ctx context.Context, opts *getNextOptions, commits []gitCommit) ([]gitCommit, error) {
+	pullCommits, err := opts.GithubClient.GetPullRequestCommits(ctx, opts.owner(), opts.repo(), opts.CheckPR)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(commits))
+	for _, c := range commits {
+		seen[c.Sha] = true
+	}
+	var missing []string
+	for _, sha := range pullCommits {
+		if !seen[sha] {
+			missing = append(missing, sha)
+		}
+	}
+	if len(missing) == 0 {
+		return commits, nil
+	}
+	if len(opts.Paths) > 0 {
+		var keep map[string]bool
+		keep, err = pathCommits(ctx, opts.RepoDir, opts.Paths, append([]string{"--no-walk"}, missing...)...)
+		if err != nil {
+			return nil, fmt.Errorf("can't read the commits of pull #%d. Are they fetched? %w", opts.CheckPR, err)
+		}
+		missing = slices.DeleteFunc(missing, func(sha string) bool { return !keep[sha] })
+	}
+	result := slices.Clone(commits)
+	for _, sha := range missing {
+		result = append(result, gitCommit{Sha: sha})
+	}
+	return result, nil
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/willabides/release-train/v3/internal/github"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
 	"github.com/willabides/release-train/v3/internal/mocks"
 	"go.uber.org/mock/gomock"
 )
@@ -15,16 +19,16 @@ import (
 func TestGetNext(t *testing.T) {
 	ctx := t.Context()
 
-	sha1 := "1aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
-	sha2 := "2aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
-	sha3 := "3aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
-	mergeSha := "4aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
 	baseTag := "v0.15.0"
+	dir, shas := initTestRepo(t, baseTag, 2)
+	sha1, sha2 := shas[0], shas[1]
+	baseSha := mustRunCmd(t, dir, "git", "rev-parse", baseTag)
+	mergeSha := "4aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
 	miscLabel := "something else"
 	repoOwner := "willabides"
 	repo := "semver-next"
 
-	cmpBaseTagToSha1 := &github.CommitComparison{AheadBy: 2, Commits: []string{sha1, sha2}}
+	cmpBaseTagToSha1 := &github.CommitComparison{AheadBy: 2}
 	cmpMergeShaToSha1 := &github.CommitComparison{AheadBy: 2}
 
 	tests := []struct {
@@ -130,8 +134,7 @@ func TestGetNext(t *testing.T) {
 			},
 		},
 		{
-			name:             "no change",
-			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0, Commits: []string{sha1, sha2}},
+			name: "no change",
 			sha1MergedPulls: []github.BasePull{
 				{Number: 1, MergeCommitSha: mergeSha, Labels: []string{miscLabel}},
 				{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelNone}},
@@ -151,8 +154,7 @@ func TestGetNext(t *testing.T) {
 			},
 		},
 		{
-			name:             "missing labels",
-			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0, Commits: []string{sha1, sha2}},
+			name: "missing labels",
 			sha1MergedPulls: []github.BasePull{
 				{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelPatch}},
 			},
@@ -165,16 +167,16 @@ func TestGetNext(t *testing.T) {
 				Base: baseTag,
 				Head: sha1,
 			},
-			wantErr: "commit 2aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa has no labels on associated pull requests: [#2 #3]",
+			wantErr: "commit " + sha2 + " has no labels on associated pull requests: [#2 #3]",
 		},
 		{
 			name:             "empty diff",
-			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0, Commits: []string{}},
+			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0},
 			options: &getNextOptions{
 				Repo:        "willabides/semver-next",
 				Base:        baseTag,
 				PrevVersion: "0.15.0",
-				Head:        sha1,
+				Head:        baseSha,
 			},
 			want: &versionChange{
 				NextVersion:     *semver.MustParse("0.15.0"),
@@ -184,13 +186,12 @@ func TestGetNext(t *testing.T) {
 		},
 		{
 			name:             "empty diff ignores minBump",
-			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0, Commits: []string{}},
-
+			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0},
 			options: &getNextOptions{
 				Repo:        "willabides/semver-next",
 				Base:        baseTag,
 				PrevVersion: "0.15.0",
-				Head:        sha1,
+				Head:        baseSha,
 				MinBump:     &[]changeLevel{changeLevelPatch}[0],
 			},
 			want: &versionChange{
@@ -200,8 +201,7 @@ func TestGetNext(t *testing.T) {
 			},
 		},
 		{
-			name:             "minBump",
-			cmpBaseTagToSha1: &github.CommitComparison{AheadBy: 0, Commits: []string{sha1, sha2}},
+			name: "minBump",
 			sha1MergedPulls: []github.BasePull{
 				{Number: 1, MergeCommitSha: mergeSha, Labels: []string{miscLabel}},
 				{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelPatch}},
@@ -225,7 +225,7 @@ func TestGetNext(t *testing.T) {
 			name:    "compareCommits error",
 			noStubs: true,
 			setupMocks: func(gh *mocks.MockGithubClient) {
-				gh.EXPECT().CompareCommits(gomock.Any(), repoOwner, repo, baseTag, sha1, -1).Return(
+				gh.EXPECT().CompareCommits(gomock.Any(), repoOwner, repo, baseTag, sha1, 0).Return(
 					nil, assert.AnError,
 				)
 			},
@@ -240,16 +240,13 @@ func TestGetNext(t *testing.T) {
 			name:    "listPullRequestsWithCommit error",
 			noStubs: true,
 			setupMocks: func(gh *mocks.MockGithubClient) {
-				gh.EXPECT().CompareCommits(gomock.Any(), repoOwner, repo, baseTag, sha1, -1).Return(
-					&github.CommitComparison{AheadBy: 0, Commits: []string{sha1, sha2, sha3}}, nil,
+				gh.EXPECT().CompareCommits(gomock.Any(), repoOwner, repo, baseTag, sha1, 0).Return(
+					&github.CommitComparison{AheadBy: 2}, nil,
 				)
 				gh.EXPECT().ListMergedPullsForCommit(gomock.Any(), repoOwner, repo, sha1).Return(
 					nil, assert.AnError,
 				)
 				gh.EXPECT().ListMergedPullsForCommit(gomock.Any(), repoOwner, repo, sha2).Return(
-					[]github.BasePull{}, nil,
-				)
-				gh.EXPECT().ListMergedPullsForCommit(gomock.Any(), repoOwner, repo, sha3).Return(
 					nil, assert.AnError,
 				)
 			},
@@ -290,7 +287,7 @@ func TestGetNext(t *testing.T) {
 			gh := mocks.NewMockGithubClient(gomock.NewController(t))
 
 			if !tt.noStubs {
-				gh.EXPECT().CompareCommits(gomock.Any(), repoOwner, repo, baseTag, gomock.Any(), -1).Return(
+				gh.EXPECT().CompareCommits(gomock.Any(), repoOwner, repo, baseTag, gomock.Any(), 0).Return(
 					ptrOr(tt.cmpBaseTagToSha1, cmpBaseTagToSha1), nil,
 				).AnyTimes()
 
@@ -323,6 +320,7 @@ func TestGetNext(t *testing.T) {
 			if tt.options.GithubClient == nil {
 				tt.options.GithubClient = gh
 			}
+			tt.options.RepoDir = cmp.Or(tt.options.RepoDir, dir)
 
 			got, err := getNext(ctx, tt.options)
 
@@ -337,6 +335,123 @@ func TestGetNext(t *testing.T) {
 	}
 }
 
+// batchGithubClient is a GithubClient that also implements BatchPullLister.
+type batchGithubClient struct {
+	*mocks.MockGithubClient
+	*mocks.MockBatchPullLister
+}
+
+func Test_compareCommits_batch(t *testing.T) {
+	dir, shas := initTestRepo(t, "v1.0.0", 2)
+	sha1, sha2 := shas[0], shas[1]
+	otherSha := "3aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	setup := func(t *testing.T) (*getNextOptions, *batchGithubClient) {
+		t.Helper()
+		ctrl := gomock.NewController(t)
+		gh := &batchGithubClient{
+			MockGithubClient:    mocks.NewMockGithubClient(ctrl),
+			MockBatchPullLister: mocks.NewMockBatchPullLister(ctrl),
+		}
+		gh.MockGithubClient.EXPECT().CompareCommits(gomock.Any(), "orgname", "repo", "v1.0.0", sha1, 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
+		)
+		return &getNextOptions{GithubClient: gh, Repo: "orgname/repo", RepoDir: dir, Base: "v1.0.0", Head: sha1}, gh
+	}
+
+	t.Run("batched", func(t *testing.T) {
+		opts, gh := setup(t)
+		gh.MockBatchPullLister.EXPECT().ListMergedPullsForCommits(gomock.Any(), "orgname", "repo", []string{sha2, sha1}).Return(
+			map[string][]github.BasePull{
+				sha2: {
+					{Number: 1, MergeCommitSha: sha1, Labels: []string{labelMinor}, Title: "add foo"},
+					{Number: 2, MergeCommitSha: otherSha, Labels: []string{labelBreaking}},
+				},
+			}, nil,
+		)
+		// sha1 is in the comparison, so only otherSha needs an ancestor check
+		gh.MockGithubClient.EXPECT().CompareCommits(gomock.Any(), "orgname", "repo", otherSha, sha1, 0).Return(
+			&github.CommitComparison{AheadBy: 1, BehindBy: 1}, nil,
+		)
+		got, err := compareCommits(t.Context(), opts)
+		require.NoError(t, err)
+		require.Equal(t, []gitCommit{
+			{Sha: sha2, Pulls: []ghPull{{
+				Number:      1,
+				LevelLabels: []string{labelMinor},
+				ChangeLevel: changeLevelMinor,
+				Title:       "add foo",
+			}}},
+			{Sha: sha1, Pulls: []ghPull{}},
+		}, got)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		opts, gh := setup(t)
+		gh.MockBatchPullLister.EXPECT().ListMergedPullsForCommits(gomock.Any(), "orgname", "repo", gomock.Any()).Return(
+			nil, github.ErrGraphQLUnsupported,
+		)
+		gh.MockGithubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgname", "repo", sha1).Return(nil, nil)
+		gh.MockGithubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgname", "repo", sha2).Return(
+			[]github.BasePull{{Number: 1, MergeCommitSha: sha1, Labels: []string{labelPatch}}}, nil,
+		)
+		got, err := compareCommits(t.Context(), opts)
+		require.NoError(t, err)
+		require.Equal(t, []gitCommit{
+			{Sha: sha2, Pulls: []ghPull{{Number: 1, LevelLabels: []string{labelPatch}, ChangeLevel: changeLevelPatch}}},
+			{Sha: sha1, Pulls: []ghPull{}},
+		}, got)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		opts, gh := setup(t)
+		gh.MockBatchPullLister.EXPECT().ListMergedPullsForCommits(gomock.Any(), "orgname", "repo", gomock.Any()).Return(
+			nil, assert.AnError,
+		)
+		_, err := compareCommits(t.Context(), opts)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_getNext_noToken(t *testing.T) {
+	t.Parallel()
+	dir, shas := initTestRepo(t, "v1.0.0", 2)
+	server := githubtest.NewServer("")
+	t.Cleanup(server.Close)
+	repo := server.AddRepo("orgname", "repo", mustRunCmd(t, dir, "git", "rev-parse", "v1.0.0"), shas[1], shas[0])
+	repo.Refs["refs/tags/v1.0.0"] = repo.Commits[0]
+	server.AddPull(repo, githubtest.Pull{Number: 1, Labels: []string{labelMinor}, Merged: true, MergeCommitSha: shas[1]})
+	server.AddPull(repo, githubtest.Pull{Number: 2, Labels: []string{labelPatch}, Merged: true, MergeCommitSha: shas[0]})
+	// GitHub's GraphQL API requires a token, so pulls are looked up with the REST API
+	client, err := github.NewClient(server.APIURL, "", "release-train/test")
+	require.NoError(t, err)
+	got, err := getNext(t.Context(), &getNextOptions{
+		GithubClient: client,
+		Repo:         "orgname/repo",
+		RepoDir:      dir,
+		Base:         "v1.0.0",
+		Head:         shas[0],
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", got.NextVersion.String())
+}
+
+// initTestRepo creates a repository with count empty commits after tag. It returns the repository's directory and the
+// shas of the commits after tag, newest first.
+func initTestRepo(t *testing.T, tag string, count int) (dir string, shas []string) {
+	t.Helper()
+	dir = t.TempDir()
+	mustRunCmd(t, dir, "sh", "-c", `
+git init -q
+git config user.name 'tester'
+git config user.email 'tester'
+git commit -q --allow-empty -m "first"
+git tag `+tag)
+	for i := range count {
+		mustRunCmd(t, dir, "git", "commit", "-q", "--allow-empty", "-m", fmt.Sprintf("commit %d", i+1))
+	}
+	return dir, strings.Split(mustRunCmd(t, dir, "git", "rev-list", tag+"..HEAD"), "\n")
+}
+
 // like cmp.Or but for nilable pointers.
 func ptrOr[T any](pointers ...*T) *T {
 	for _, p := range pointers {
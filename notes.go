@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+)
+
+const (
+	releaseNotesGithub  = "github"
+	releaseNotesBuiltin = "builtin"
+)
+
+// defaultNotesTemplate renders pulls grouped by change level as markdown lists.
+const defaultNotesTemplate = `
+{{- define "pull" -}}
+- {{ or .Title .String }}
+{{- if .Number }} ({{ if .URL }}[#{{ .Number }}]({{ .URL }}){{ else }}#{{ .Number }}{{ end }}){{ end }}
+{{- if .Author }} by @{{ .Author }}{{ end }}
+{{ end -}}
+
+{{- with .Breaking }}
+### Breaking Changes
+
+{{ range . }}{{ template "pull" . }}{{ end }}
+{{- end }}
+{{- with .Features }}
+### Features
+
+{{ range . }}{{ template "pull" . }}{{ end }}
+{{- end }}
+{{- with .Fixes }}
+### Fixes
+
+{{ range . }}{{ template "pull" . }}{{ end }}
+{{- end }}
+{{- with .Other }}
+### Other Changes
+
+{{ range . }}{{ template "pull" . }}{{ end }}
+{{- end }}
+`
+
+// releaseNotesData is the data available to release notes templates.
+type releaseNotesData struct {
+	Repo            string
+	Tag             string
+	PreviousTag     string
+	Version         string
+	PreviousVersion string
+	ChangeLevel     changeLevel
+
+	// Pulls contains every pull in the release. The rest are Pulls grouped by change level.
+	Pulls    ghPulls
+	Breaking ghPulls
+	Features ghPulls
+	Fixes    ghPulls
+	Other    ghPulls
+}
+
+// newReleaseNotesData returns the data for rendering notes. Release PRs are left out because they only contain the
+// release's own file changes.
+func newReleaseNotesData(repo string, result *Result, pulls ghPulls) *releaseNotesData {
+	pulls = pulls.filter(func(pull ghPull) bool {
+		return !pull.ReleasePR
+	})
+	data := releaseNotesData{
+		Repo:            repo,
+		Tag:             result.ReleaseTag,
+		PreviousTag:     result.PreviousRef,
+		PreviousVersion: result.PreviousVersion,
+		ChangeLevel:     result.ChangeLevel,
+		Pulls:           pulls,
+	}
+	if result.ReleaseVersion != nil {
+		data.Version = result.ReleaseVersion.String()
+	}
+	for _, pull := range pulls {
+		switch pull.ChangeLevel {
+		case changeLevelMajor:
+			data.Breaking = append(data.Breaking, pull)
+		case changeLevelMinor:
+			data.Features = append(data.Features, pull)
+		case changeLevelPatch:
+			data.Fixes = append(data.Fixes, pull)
+		default:
+			data.Other = append(data.Other, pull)
+		}
+	}
+	return &data
+}
+
+// parseNotesTemplate parses a release notes template. An empty text uses defaultNotesTemplate.
+func parseNotesTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultNotesTemplate
+	}
+	return template.New("release-notes").Option("missingkey=error").Parse(text)
+}
+
+// renderReleaseNotes executes tmpl with data and trims surrounding whitespace from the output.
+func renderReleaseNotes(tmpl *template.Template, data *releaseNotesData) (string, error) {
+	var buf strings.Builder
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
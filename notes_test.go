@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_renderReleaseNotes(t *testing.T) {
+	result := &Result{
+		PreviousRef:     "v1.2.3",
+		PreviousVersion: "1.2.3",
+		ReleaseTag:      "v2.0.0",
+		ReleaseVersion:  semver.MustParse("2.0.0"),
+		ChangeLevel:     changeLevelMajor,
+	}
+	pulls := ghPulls{
+		{Number: 1, ChangeLevel: changeLevelMinor, Title: "add foo", Author: "alice", URL: "https://github.com/o/r/pull/1"},
+		{Number: 2, ChangeLevel: changeLevelMajor, Title: "remove bar", Author: "bob", URL: "https://github.com/o/r/pull/2"},
+		{Number: 3, ChangeLevel: changeLevelNone, Title: "update docs"},
+		{Number: 4, ChangeLevel: changeLevelMinor, Title: "add baz", Author: "alice", URL: "https://github.com/o/r/pull/4"},
+		{Commit: "abc123", ChangeLevel: changeLevelPatch, Title: "fix: qux"},
+		{Commit: "def456", ChangeLevel: changeLevelPatch},
+	}
+	data := newReleaseNotesData("o/r", result, pulls)
+
+	t.Run("default template", func(t *testing.T) {
+		tmpl, err := parseNotesTemplate("")
+		require.NoError(t, err)
+		got, err := renderReleaseNotes(tmpl, data)
+		require.NoError(t, err)
+		require.Equal(t, `### Breaking Changes
+
+- remove bar ([#2](https://github.com/o/r/pull/2)) by @bob
+
+### Features
+
+- add foo ([#1](https://github.com/o/r/pull/1)) by @alice
+- add baz ([#4](https://github.com/o/r/pull/4)) by @alice
+
+### Fixes
+
+- fix: qux
+- def456
+
+### Other Changes
+
+- update docs (#3)`, got)
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		tmpl, err := parseNotesTemplate(`
+{{ .PreviousTag }}...{{ .Tag }} ({{ .ChangeLevel }})
+{{ range .Pulls }}{{ . }} {{ end }}
+{{ len .Features }} features
+`)
+		require.NoError(t, err)
+		got, err := renderReleaseNotes(tmpl, data)
+		require.NoError(t, err)
+		require.Equal(t, "v1.2.3...v2.0.0 (major)\n#1 #2 #3 #4 abc123 def456 \n2 features", got)
+	})
+
+	t.Run("no pulls", func(t *testing.T) {
+		tmpl, err := parseNotesTemplate("")
+		require.NoError(t, err)
+		got, err := renderReleaseNotes(tmpl, newReleaseNotesData("o/r", result, nil))
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		tmpl, err := parseNotesTemplate(`{{ .Nope }}`)
+		require.NoError(t, err)
+		_, err = renderReleaseNotes(tmpl, data)
+		require.ErrorContains(t, err, "can't evaluate field Nope")
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"strconv"
 )
 
@@ -73,5 +74,72 @@ func outputItems() []outputItem {
 			description: `Whether pre-tag-hook issued an abort by exiting 10. Either "true" or "false".`,
 			value:       func(r *Result) string { return strconv.FormatBool(r.PreTagHookAborted) },
 		},
+		{
+			name:        "post-tag-hook-output",
+			description: `The stdout of the post-tag-hook. Empty if post-tag-hook is not set or didn't run.`,
+			value:       func(r *Result) string { return r.PostTagHookOutput },
+		},
+		{
+			name:        "post-release-hook-output",
+			description: `The stdout of the post-release-hook. Empty if post-release-hook is not set or didn't run.`,
+			value:       func(r *Result) string { return r.PostReleaseHookOutput },
+		},
+		{
+			name:        "release-url",
+			description: `The URL of the published release. Empty if no release was published.`,
+			value:       func(r *Result) string { return r.ReleaseURL },
+		},
+		{
+			name:        "alias-tags",
+			description: `A JSON array of the alias tags that were moved to the release, such as ["v3","v3.2"]. Empty when none were moved.`,
+			value: func(r *Result) string {
+				if len(r.AliasTags) == 0 {
+					return ""
+				}
+				b, err := json.Marshal(r.AliasTags)
+				if err != nil {
+					panic(err)
+				}
+				return string(b)
+			},
+		},
+		{
+			name:        "release-pull-request",
+			description: `The number of the release PR that was opened or updated. Empty unless release-pr updated one.`,
+			value: func(r *Result) string {
+				if r.ReleasePullRequest == 0 {
+					return ""
+				}
+				return strconv.Itoa(r.ReleasePullRequest)
+			},
+		},
+		{
+			name:        "pulls",
+			description: `A JSON array of the PRs in the release. Each has its "number", level "labels", resolved "change_level", "has_pre_label", "pre_release_prefix" and "has_stable_label" along with its "title", "author" and "url". Commits that got their level from their message have "commit" instead of "number". Empty when there are no PRs.`,
+			value: func(r *Result) string {
+				if len(r.Pulls) == 0 {
+					return ""
+				}
+				b, err := json.Marshal(r.Pulls)
+				if err != nil {
+					panic(err)
+				}
+				return string(b)
+			},
+		},
+		{
+			name:        "components",
+			description: `A JSON array with the result of each component when components are set. Each result has the component's "name" and the same fields as the json output format. Empty when no components are set.`,
+			value: func(r *Result) string {
+				if len(r.Components) == 0 {
+					return ""
+				}
+				b, err := json.Marshal(r.Components)
+				if err != nil {
+					panic(err)
+				}
+				return string(b)
+			},
+		},
 	}
 }
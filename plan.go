@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Plan describes what run would do without doing it.
+type Plan struct {
+	Result *Result `json:"result"`
+	// PreTagHook is true when run would run the pre-tag hook. The plan doesn't run it, so changes the hook would make to
+	// the release target, release notes or assets aren't included, and the hook may still abort the release.
+	PreTagHook bool `json:"pre-tag-hook,omitempty"`
+	// SkipReason explains why no tag would be created. Empty when one would be.
+	SkipReason string       `json:"skip-reason,omitempty"`
+	Commit     *PlanCommit  `json:"commit,omitempty"`
+	Tag        *PlanTag     `json:"tag,omitempty"`
+	Push       *PlanPush    `json:"push,omitempty"`
+	Release    *PlanRelease `json:"release,omitempty"`
+	// AliasTags are the alias tags that would be moved to the release.
+	AliasTags []string `json:"alias-tags,omitempty"`
+	// ReleasePR is set instead of Commit, Tag, Push and Release when --release-pr would update the release PR.
+	ReleasePR *PlanReleasePR `json:"release-pr,omitempty"`
+}
+
+// PlanReleasePR is the release PR that would be opened or updated.
+type PlanReleasePR struct {
+	Branch string `json:"branch"`
+	Base   string `json:"base"`
+	// Parent is the sha of the base the release PR's commit is made on top of.
+	Parent string   `json:"parent"`
+	Files  []string `json:"files"`
+}
+
+// PlanCommit is the release commit made for files such as the changelog.
+type PlanCommit struct {
+	Message string `json:"message"`
+	// Parent is the sha of the release target the commit is made on top of.
+	Parent string   `json:"parent"`
+	Files  []string `json:"files"`
+}
+
+// PlanTag is the tag that would be pushed.
+type PlanTag struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	// Sha is the commit the tag would point to. It is empty when the tag is on a release commit that doesn't exist yet.
+	Sha string `json:"sha,omitempty"`
+	// Existing is true when the pre-tag hook already created the tag locally.
+	Existing bool   `json:"existing,omitempty"`
+	Remote   string `json:"remote"`
+	// Message is set when the tag would be annotated.
+	Message string `json:"message,omitempty"`
+	Signed  bool   `json:"signed,omitempty"`
+	// API is true when the tag would be created with the Git Data API instead of pushed to Remote.
+	API bool `json:"api,omitempty"`
+}
+
+// PlanPush is the release target branch that would be pushed.
+type PlanPush struct {
+	Remote string `json:"remote"`
+	Branch string `json:"branch"`
+	// API is true when the branch would be moved with the Git Data API instead of pushed to Remote.
+	API bool `json:"api,omitempty"`
+}
+
+// PlanRelease is the release that would be created.
+type PlanRelease struct {
+	Name        string      `json:"name"`
+	Prerelease  bool        `json:"prerelease"`
+	Draft       bool        `json:"draft"`
+	NotesSource string      `json:"notes-source"`
+	Assets      []PlanAsset `json:"assets"`
+	// MakeLatest is the make_latest value used when the release is published. Empty for drafts.
+	MakeLatest string `json:"make-latest,omitempty"`
+}
+
+// PlanAsset is a file from ASSETS_DIR that would be uploaded.
+type PlanAsset struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// plan walks the same steps as run but stops short of committing, tagging, pushing or creating a release. The pre-tag
+// hook isn't run because it can make changes of its own. Plan.PreTagHook reports that it would run.
+func (o *Runner) plan(ctx context.Context) (*Plan, error) {
+	if o.ran {
+		panic("Runner.plan called after Runner.run, this is not allowed")
+	}
+	o.ran = true
+	slog.Debug("starting plan")
+	err := o.rejectShallowCheckout(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := o.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := Plan{Result: result}
+
+	if !result.FirstRelease &&
+		result.ReleaseVersion != nil &&
+		result.PreviousVersion == result.ReleaseVersion.String() {
+		plan.SkipReason = "no changes since the previous release"
+		return &plan, nil
+	}
+
+	err = o.assertTagNotExists(ctx, o.PushRemote, result.ReleaseTag)
+	if err != nil {
+		return nil, err
+	}
+
+	releasePRPending, err := o.releasePRPending(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if releasePRPending {
+		err = o.checkGoModule(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+		err = o.checkGoAPI(ctx)
+		if err != nil {
+			return nil, err
+		}
+		plan.SkipReason = o.skipTagReason(ctx)
+		if plan.SkipReason != "" {
+			return &plan, nil
+		}
+		plan.ReleasePR, err = o.planReleasePR(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+		return &plan, nil
+	}
+
+	plan.PreTagHook = o.PreTagHook != ""
+	err = o.checkGoModule(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	err = o.checkGoAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case result.ReleaseVersion == nil:
+		plan.SkipReason = "there is no release version"
+	default:
+		plan.SkipReason = o.skipTagReason(ctx)
+	}
+	if plan.SkipReason != "" {
+		return &plan, nil
+	}
+
+	plan.Tag, plan.Commit, err = o.planTag(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// run pushes the target after a release is created unless it is a draft. Without a release, the target is only
+	// pushed when there is a release commit.
+	if (o.CreateRelease && !o.Draft) || (!o.CreateRelease && plan.Commit != nil) {
+		var branch string
+		_, branch, err = o.targetBranch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if branch != "" {
+			plan.Push = &PlanPush{Remote: o.PushRemote, Branch: branch, API: o.APIPush}
+		}
+	}
+
+	if o.CreateRelease {
+		plan.Release, err = o.planRelease(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !o.CreateRelease || !o.Draft {
+		plan.AliasTags, err = o.aliasTags(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &plan, nil
+}
+
+// planTag returns the tag run would push and the release commit it would make first, if any.
+func (o *Runner) planTag(ctx context.Context, result *Result) (*PlanTag, *PlanCommit, error) {
+	target, err := o.getReleaseTarget()
+	if err != nil {
+		return nil, nil, err
+	}
+	tag := PlanTag{Name: result.ReleaseTag, Target: target, Remote: o.PushRemote, API: o.APIPush}
+	tag.Existing, err = localTagExists(ctx, o.CheckoutDir, result.ReleaseTag)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tag.Existing {
+		tag.Target = result.ReleaseTag
+	} else if o.annotatedTag() {
+		tag.Message, err = o.tagMessage(result)
+		if err != nil {
+			return nil, nil, err
+		}
+		tag.Signed = o.SignTags
+	}
+	sha, err := o.runCmd(ctx, nil, "git", "rev-parse", "--verify", tag.Target+"^{commit}")
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := o.releaseCommitChanges(ctx, result)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		tag.Sha = sha
+		return &tag, nil, nil
+	}
+	if tag.Existing {
+		return nil, nil, fmt.Errorf("cannot commit release files because the pre-tag hook created tag %q", result.ReleaseTag)
+	}
+	commit := PlanCommit{
+		Message: "Release " + result.ReleaseTag,
+		Parent:  sha,
+		Files:   make([]string, len(changes)),
+	}
+	for i, change := range changes {
+		commit.Files[i] = change.Path
+	}
+	return &tag, &commit, nil
+}
+
+// planReleasePR returns the release PR updateReleasePR would open or update.
+func (o *Runner) planReleasePR(ctx context.Context, result *Result) (*PlanReleasePR, error) {
+	parent, base, err := o.releasePRBase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := o.releaseFileChanges(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	pr := PlanReleasePR{
+		Branch: o.ReleasePRBranch,
+		Base:   base,
+		Parent: parent,
+		Files:  make([]string, len(changes)),
+	}
+	for i, change := range changes {
+		pr.Files[i] = change.Path
+	}
+	return &pr, nil
+}
+
+// planRelease returns the release createRelease would create from the notes and assets that are already in place.
+func (o *Runner) planRelease(ctx context.Context, result *Result) (*PlanRelease, error) {
+	source, err := o.releaseNotesSource(result)
+	if err != nil {
+		return nil, err
+	}
+	release := PlanRelease{
+		Name:        result.ReleaseTag,
+		Prerelease:  result.ReleaseVersion.Prerelease() != "",
+		Draft:       o.Draft,
+		NotesSource: source,
+		Assets:      []PlanAsset{},
+	}
+	if !o.Draft {
+		release.MakeLatest, err = o.makeLatest(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	assets, err := filepath.Glob(filepath.Join(o.assetsDir(), "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, asset := range assets {
+		info, e := os.Stat(asset)
+		if e != nil {
+			return nil, e
+		}
+		release.Assets = append(release.Assets, PlanAsset{Name: filepath.Base(asset), Size: info.Size()})
+	}
+	return &release, nil
+}
+
+// writeText writes the plan as a list of steps.
+func (p *Plan) writeText(w io.Writer) error {
+	var b strings.Builder
+	r := p.Result
+	switch {
+	case r.ReleaseVersion == nil:
+		b.WriteString("No release version\n")
+	case r.FirstRelease:
+		fmt.Fprintf(&b, "First release %s\n", r.ReleaseTag)
+	default:
+		fmt.Fprintf(&b, "Release %s (%s change since %s)\n", r.ReleaseTag, r.ChangeLevel, r.PreviousRef)
+	}
+	step := 0
+	addStep := func(format string, args ...any) {
+		step++
+		fmt.Fprintf(&b, "%d. %s\n", step, fmt.Sprintf(format, args...))
+	}
+	if p.PreTagHook {
+		addStep("run the pre-tag hook (not run by plan; it may change the release target, notes and assets or abort)")
+	}
+	if p.SkipReason != "" {
+		fmt.Fprintf(&b, "Nothing to do: %s\n", p.SkipReason)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	if p.ReleasePR != nil {
+		pr := p.ReleasePR
+		addStep("commit %s onto %s and force-push it to %s", strings.Join(pr.Files, ", "), pr.Parent, pr.Branch)
+		addStep("open or update the release PR from %s into %s", pr.Branch, pr.Base)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	if p.Commit != nil {
+		addStep("commit %s onto %s: %q", strings.Join(p.Commit.Files, ", "), p.Commit.Parent, p.Commit.Message)
+	}
+	tagDest := pushDestination(p.Tag.Remote, p.Tag.API)
+	switch {
+	case p.Tag.Existing:
+		addStep("push tag %s created by the pre-tag hook at %s to %s", p.Tag.Name, p.Tag.Sha, tagDest)
+	case p.Commit != nil:
+		addStep("tag the release commit as %s and push it to %s", p.Tag.Name, tagDest)
+	default:
+		addStep("tag %s (%s) as %s and push it to %s", p.Tag.Sha, p.Tag.Target, p.Tag.Name, tagDest)
+	}
+	rel := p.Release
+	if rel != nil {
+		kind := "release"
+		if rel.Prerelease {
+			kind = "prerelease"
+		}
+		addStep("create draft %s %s with notes from %s", kind, rel.Name, rel.NotesSource)
+		for _, asset := range rel.Assets {
+			addStep("upload asset %s (%d bytes)", asset.Name, asset.Size)
+		}
+	}
+	if p.Push != nil {
+		addStep("push %s to %s", p.Push.Branch, pushDestination(p.Push.Remote, p.Push.API))
+	}
+	if rel != nil {
+		if rel.Draft {
+			addStep("leave release %s as a draft", rel.Name)
+		} else {
+			addStep("publish release %s with make_latest=%s", rel.Name, rel.MakeLatest)
+		}
+	}
+	if len(p.AliasTags) > 0 {
+		addStep("move %s to %s and force-push them to %s",
+			strings.Join(p.AliasTags, ", "), p.Tag.Name, pushDestination(p.Tag.Remote, p.Tag.API))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// pushDestination describes where a tag or branch is pushed in writeText.
+func pushDestination(remote string, api bool) string {
+	if api {
+		return "GitHub with the Git Data API"
+	}
+	return remote
+}
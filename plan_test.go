@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_plan(t *testing.T) {
+	t.Parallel()
+
+	// setup returns a clone of a repo whose origin has v1.0.0 followed by a feature commit.
+	setup := func(t *testing.T) (clone, head string) {
+		t.Helper()
+		_, clone = setupTestRepo(t, "v1.0.0", `
+printf '# Changelog\n\n## [Unreleased]\n' > CHANGELOG.md
+git add CHANGELOG.md
+git commit -q -m "add changelog"
+`)
+		return clone, mustRunCmd(t, clone, "git", "rev-parse", "HEAD")
+	}
+
+	newRunner := func(t *testing.T, clone string) *Runner {
+		t.Helper()
+		runner := newTestRunner(t, clone)
+		runner.CreateTag = false
+		runner.MakeLatest = "true"
+		return runner
+	}
+
+	wantResult := func(head string) *Result {
+		return &Result{
+			PreviousRef:           "v1.0.0",
+			PreviousVersion:       "1.0.0",
+			PreviousStableRef:     "v1.0.0",
+			PreviousStableVersion: "1.0.0",
+			ReleaseVersion:        semver.MustParse("1.1.0"),
+			ReleaseTag:            "v1.1.0",
+			ChangeLevel:           changeLevelMinor,
+			Pulls: ghPulls{{
+				Commit:      head,
+				Title:       "feat: add foo",
+				LevelLabels: []string{labelMinor},
+				ChangeLevel: changeLevelMinor,
+			}},
+		}
+	}
+
+	requireUnchanged := func(t *testing.T, clone, head string) {
+		t.Helper()
+		require.Equal(t, head, mustRunCmd(t, clone, "git", "rev-parse", "HEAD"))
+		require.Empty(t, mustRunCmd(t, clone, "git", "tag", "--list", "v1.1.0"))
+		require.Empty(t, mustRunCmd(t, clone, "git", "ls-remote", "--tags", "origin", "v1.1.0"))
+		require.Equal(t, head, mustRunCmd(t, clone, "git", "ls-remote", "origin", "refs/heads/main")[:len(head)])
+	}
+
+	t.Run("release", func(t *testing.T) {
+		t.Parallel()
+		clone, head := setup(t)
+		runner := newRunner(t, clone)
+		runner.CreateRelease = true
+		require.NoError(t, os.MkdirAll(runner.assetsDir(), 0o700))
+		require.NoError(t, os.WriteFile(runner.releaseNotesFile(), []byte("notes"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(runner.assetsDir(), "foo.txt"), []byte("hello"), 0o600))
+		got, err := runner.plan(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, &Plan{
+			Result: wantResult(head),
+			Tag: &PlanTag{
+				Name:   "v1.1.0",
+				Target: "main",
+				Sha:    head,
+				Remote: "origin",
+			},
+			Push: &PlanPush{Remote: "origin", Branch: "refs/heads/main"},
+			Release: &PlanRelease{
+				Name:        "v1.1.0",
+				NotesSource: notesSourceFile,
+				Assets:      []PlanAsset{{Name: "foo.txt", Size: 5}},
+				MakeLatest:  "true",
+			},
+		}, got)
+		requireUnchanged(t, clone, head)
+	})
+
+	t.Run("changelog commit", func(t *testing.T) {
+		t.Parallel()
+		clone, head := setup(t)
+		runner := newRunner(t, clone)
+		runner.CreateTag = true
+		runner.Changelog = "CHANGELOG.md"
+		got, err := runner.plan(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, &Plan{
+			Result: wantResult(head),
+			Commit: &PlanCommit{
+				Message: "Release v1.1.0",
+				Parent:  head,
+				Files:   []string{"CHANGELOG.md"},
+			},
+			Tag:  &PlanTag{Name: "v1.1.0", Target: "main", Remote: "origin"},
+			Push: &PlanPush{Remote: "origin", Branch: "refs/heads/main"},
+		}, got)
+		requireUnchanged(t, clone, head)
+		content, err := os.ReadFile(filepath.Join(clone, "CHANGELOG.md"))
+		require.NoError(t, err)
+		require.Equal(t, "# Changelog\n\n## [Unreleased]\n", string(content))
+	})
+
+	t.Run("no create tag", func(t *testing.T) {
+		t.Parallel()
+		clone, head := setup(t)
+		got, err := newRunner(t, clone).plan(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, &Plan{
+			Result:     wantResult(head),
+			SkipReason: "neither --create-tag nor --create-release is set",
+		}, got)
+		requireUnchanged(t, clone, head)
+	})
+
+	t.Run("pre-tag hook isn't run", func(t *testing.T) {
+		t.Parallel()
+		clone, head := setup(t)
+		runner := newRunner(t, clone)
+		runner.CreateTag = true
+		runner.PreTagHook = `
+git tag v1.1.0
+git commit -q --allow-empty -m "hook commit"
+`
+		got, err := runner.plan(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, &Plan{
+			Result:     wantResult(head),
+			PreTagHook: true,
+			Tag:        &PlanTag{Name: "v1.1.0", Target: "main", Sha: head, Remote: "origin"},
+		}, got)
+		requireUnchanged(t, clone, head)
+	})
+}
+
+func TestPlan_writeText(t *testing.T) {
+	t.Parallel()
+	result := &Result{
+		PreviousRef:    "v1.0.0",
+		ReleaseVersion: semver.MustParse("1.1.0"),
+		ReleaseTag:     "v1.1.0",
+		ChangeLevel:    changeLevelMinor,
+	}
+
+	t.Run("release", func(t *testing.T) {
+		t.Parallel()
+		plan := Plan{
+			Result: result,
+			Tag:    &PlanTag{Name: "v1.1.0", Target: "main", Sha: "abc123", Remote: "origin"},
+			Push:   &PlanPush{Remote: "origin", Branch: "refs/heads/main"},
+			Release: &PlanRelease{
+				Name:        "v1.1.0",
+				NotesSource: notesSourceGithub,
+				Assets:      []PlanAsset{{Name: "foo.txt", Size: 5}, {Name: "bar.txt", Size: 12}},
+				MakeLatest:  "legacy",
+			},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, plan.writeText(&buf))
+		require.Equal(t, `Release v1.1.0 (minor change since v1.0.0)
+1. tag abc123 (main) as v1.1.0 and push it to origin
+2. create draft release v1.1.0 with notes from github
+3. upload asset foo.txt (5 bytes)
+4. upload asset bar.txt (12 bytes)
+5. push refs/heads/main to origin
+6. publish release v1.1.0 with make_latest=legacy
+`, buf.String())
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		t.Parallel()
+		plan := Plan{
+			Result: result,
+			Commit: &PlanCommit{Message: "Release v1.1.0", Parent: "abc123", Files: []string{"CHANGELOG.md"}},
+			Tag:    &PlanTag{Name: "v1.1.0", Target: "main", Remote: "origin"},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, plan.writeText(&buf))
+		require.Equal(t, `Release v1.1.0 (minor change since v1.0.0)
+1. commit CHANGELOG.md onto abc123: "Release v1.1.0"
+2. tag the release commit as v1.1.0 and push it to origin
+`, buf.String())
+	})
+
+	t.Run("pre-tag hook", func(t *testing.T) {
+		t.Parallel()
+		plan := Plan{
+			Result:     result,
+			PreTagHook: true,
+			Tag:        &PlanTag{Name: "v1.1.0", Target: "main", Sha: "abc123", Remote: "origin"},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, plan.writeText(&buf))
+		require.Equal(t, `Release v1.1.0 (minor change since v1.0.0)
+1. run the pre-tag hook (not run by plan; it may change the release target, notes and assets or abort)
+2. tag abc123 (main) as v1.1.0 and push it to origin
+`, buf.String())
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		t.Parallel()
+		plan := Plan{Result: result, SkipReason: "--check-pr is set"}
+		var buf bytes.Buffer
+		require.NoError(t, plan.writeText(&buf))
+		require.Equal(t, "Release v1.1.0 (minor change since v1.0.0)\nNothing to do: --check-pr is set\n", buf.String())
+	})
+}
@@ -5,17 +5,25 @@ import (
 	"fmt"
 	"slices"
 	"sort"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 )
 
 type ghPull struct {
-	Number           int         `json:"number"`
+	Number           int         `json:"number,omitempty"`
 	LevelLabels      []string    `json:"labels,omitempty"`
 	ChangeLevel      changeLevel `json:"change_level"`
 	HasPreLabel      bool        `json:"has_pre_label,omitempty"`
 	PreReleasePrefix string      `json:"pre_release_prefix,omitempty"`
 	HasStableLabel   bool        `json:"has_stable_label,omitempty"`
+	Title            string      `json:"title,omitempty"`
+	Author           string      `json:"author,omitempty"`
+	URL              string      `json:"url,omitempty"`
+	ReleasePR        bool        `json:"release_pr,omitempty"`
+
+	// Commit is set instead of Number when the labels come from a commit rather than a pull request.
+	Commit string `json:"commit,omitempty"`
 }
 
 func newPull(number int, aliases map[string]string, labels ...string) (*ghPull, error) {
@@ -46,6 +54,9 @@ func newPull(number int, aliases map[string]string, labels ...string) (*ghPull,
 		if resolvedLabel == labelStable {
 			p.HasStableLabel = true
 		}
+		if strings.EqualFold(label, labelReleasePR) {
+			p.ReleasePR = true
+		}
 	}
 	if p.HasPreLabel && p.HasStableLabel {
 		return nil, fmt.Errorf("pull #%d has both prerelease and stable labels", number)
@@ -54,6 +65,9 @@ func newPull(number int, aliases map[string]string, labels ...string) (*ghPull,
 }
 
 func (p ghPull) String() string {
+	if p.Number == 0 && p.Commit != "" {
+		return p.Commit
+	}
 	return fmt.Sprintf("#%d", p.Number)
 }
 
@@ -83,6 +97,13 @@ func (p ghPulls) unstable() ghPulls {
 	})
 }
 
+// hasReleasePR returns true if one of the pulls is a release PR.
+func (p ghPulls) hasReleasePR() bool {
+	return slices.ContainsFunc(p, func(pull ghPull) bool {
+		return pull.ReleasePR
+	})
+}
+
 // prerelease returns pulls that have the prerelease label.
 func (p ghPulls) prerelease() ghPulls {
 	return p.filter(func(pull ghPull) bool {
@@ -97,14 +118,19 @@ func (p ghPulls) nonPrerelease() ghPulls {
 	})
 }
 
-// compact sorts and removes duplicate pulls based on their number.
+// compact sorts and removes duplicate pulls based on their number and commit. Of the duplicates, the one with the
+// highest change level is kept.
 func (p ghPulls) compact() ghPulls {
 	pulls := slices.Clone(p)
-	slices.SortFunc(pulls, func(a, b ghPull) int {
-		return cmp.Compare(a.Number, b.Number)
+	slices.SortStableFunc(pulls, func(a, b ghPull) int {
+		return cmp.Or(
+			cmp.Compare(a.Number, b.Number),
+			cmp.Compare(a.Commit, b.Commit),
+			cmp.Compare(b.ChangeLevel, a.ChangeLevel),
+		)
 	})
 	return slices.CompactFunc(pulls, func(a, b ghPull) bool {
-		return a.Number == b.Number
+		return a.Number == b.Number && a.Commit == b.Commit
 	})
 }
 
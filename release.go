@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -13,14 +14,18 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/willabides/release-train/v3/internal/github"
 )
 
 type Runner struct {
 	CheckoutDir     string
 	Ref             string
 	GithubToken     string
+	GithubTokenFunc func(context.Context) (string, error)
 	CreateTag       bool
 	CreateRelease   bool
 	Draft           bool
@@ -30,12 +35,35 @@ type Runner struct {
 	TagPrefix       string
 	InitialTag      string
 	PreTagHook      string
+	PostTagHook     string
+	PostReleaseHook string
 	Repo            string
 	PushRemote      string
 	TempDir         string
 	MakeLatest      string
+	ReleaseNotes    string
+	NotesTemplate   *template.Template
+	TagMessage      *template.Template
+	SignTags        bool
+	SigningKey      string
+	SigningFormat   string
+	Changelog       string
+	VersionFiles    []*versionFile
+	Components      []*component
+	Component       string
+	Paths           []string
+	GoModule        bool
+	GoAPICheck      bool
+	APIPush         bool
+	AliasTags       bool
+	AliasLatest     bool
+	ReleasePR       bool
+	ReleasePRBranch string
 	ReleaseRefs     []string
 	LabelAliases    map[string]string
+	GitOnly         bool
+	LabelCache      string
+	LevelSource     string
 	CheckPR         int
 	GithubClient    GithubClient
 	Stdout          io.Writer
@@ -43,12 +71,22 @@ type Runner struct {
 
 	ran         bool
 	errCleanups []func() error
+	// cancelTargetReset keeps the release target's branch at the release commit once it has been pushed.
+	cancelTargetReset func()
+	pulls             ghPulls
+	now               func() time.Time
+	// apiCommits maps local commits to the commits apiCommit created for them.
+	apiCommits map[string]string
 }
 
 func (o *Runner) releaseNotesFile() string {
 	return filepath.Join(o.TempDir, "release-notes")
 }
 
+func (o *Runner) releasePullsFile() string {
+	return filepath.Join(o.TempDir, "release-pulls.json")
+}
+
 func (o *Runner) releaseTargetFile() string {
 	return filepath.Join(o.TempDir, "release-target")
 }
@@ -93,15 +131,27 @@ type Result struct {
 	ReleaseVersion        *semver.Version `json:"release-version,omitempty"`
 	ReleaseTag            string          `json:"release-tag,omitempty"`
 	ChangeLevel           changeLevel     `json:"change-level"`
+	Pulls                 ghPulls         `json:"pulls,omitempty"`
 	CreatedTag            bool            `json:"created-tag,omitempty"`
 	CreatedRelease        bool            `json:"created-release,omitempty"`
 	PrereleaseHookOutput  string          `json:"prerelease-hook-output"`
 	PrereleaseHookAborted bool            `json:"prerelease-hook-aborted"`
 	PreTagHookOutput      string          `json:"pre-tag-hook-output"`
 	PreTagHookAborted     bool            `json:"pre-tag-hook-aborted"`
+	PostTagHookOutput     string          `json:"post-tag-hook-output,omitempty"`
+	PostReleaseHookOutput string          `json:"post-release-hook-output,omitempty"`
+	ReleaseURL            string          `json:"release-url,omitempty"`
+	AliasTags             []string        `json:"alias-tags,omitempty"`
+	ReleasePullRequest    int             `json:"release-pull-request,omitempty"`
+
+	// Components has the result of each component when --component is set.
+	Components []ComponentResult `json:"components,omitempty"`
 }
 
 func (o *Runner) next(ctx context.Context) (*Result, error) {
+	if len(o.Components) > 0 {
+		return o.eachComponent(ctx, (*Runner).next)
+	}
 	slog.Debug("starting release next")
 	ref := cmp.Or(o.Ref, "HEAD")
 	head, err := o.runCmd(ctx, nil, "git", "rev-parse", ref)
@@ -149,8 +199,12 @@ func (o *Runner) next(ctx context.Context) (*Result, error) {
 	}
 
 	var nextRes *versionChange
-	nextRes, err = getNext(ctx, &getNextOptions{
+	nextRes, o.pulls, err = getNextWithPulls(ctx, &getNextOptions{
 		Repo:            o.Repo,
+		RepoDir:         o.CheckoutDir,
+		GitOnly:         o.GitOnly,
+		LabelCache:      o.LabelCache,
+		LevelSource:     o.LevelSource,
 		GithubClient:    o.GithubClient,
 		PrevVersion:     prevVersion.String(),
 		Base:            prevRef,
@@ -160,6 +214,7 @@ func (o *Runner) next(ctx context.Context) (*Result, error) {
 		CheckPR:         o.CheckPR,
 		ForcePrerelease: o.ForcePrerelease,
 		ForceStable:     o.ForceStable,
+		Paths:           o.Paths,
 	})
 	if err != nil {
 		return nil, err
@@ -167,6 +222,7 @@ func (o *Runner) next(ctx context.Context) (*Result, error) {
 	result.ReleaseVersion = &nextRes.NextVersion
 	result.ReleaseTag = o.TagPrefix + nextRes.NextVersion.String()
 	result.ChangeLevel = nextRes.ChangeLevel
+	result.Pulls = o.pulls
 	slog.Debug("returning from release next", slog.Any("result", result))
 	return &result, nil
 }
@@ -247,37 +303,86 @@ func (o *Runner) getReleaseTarget() (string, error) {
 	return target, nil
 }
 
-func (o *Runner) getReleaseNotes(ctx context.Context, result *Result) (string, error) {
+// Release notes sources reported by releaseNotesSource.
+const (
+	notesSourceFile    = "release-notes-file"
+	notesSourceEmpty   = "empty"
+	notesSourceBuiltin = releaseNotesBuiltin
+	notesSourceGithub  = releaseNotesGithub
+)
+
+// releaseNotesSource returns where getReleaseNotes will get the release notes from.
+func (o *Runner) releaseNotesSource(result *Result) (string, error) {
 	notesInfo, err := os.Stat(o.releaseNotesFile())
 	if err != nil && !os.IsNotExist(err) {
 		return "", err
 	}
 	if err == nil && !notesInfo.IsDir() {
+		return notesSourceFile, nil
+	}
+	// first release is empty by default
+	if result.FirstRelease {
+		return notesSourceEmpty, nil
+	}
+	if o.ReleaseNotes == releaseNotesBuiltin {
+		return notesSourceBuiltin, nil
+	}
+	return notesSourceGithub, nil
+}
+
+func (o *Runner) getReleaseNotes(ctx context.Context, result *Result) (string, error) {
+	source, err := o.releaseNotesSource(result)
+	if err != nil {
+		return "", err
+	}
+	switch source {
+	case notesSourceFile:
 		content, e := os.ReadFile(o.releaseNotesFile())
 		if e != nil {
 			return "", e
 		}
 		return string(content), nil
-	}
-	// first release is empty by default
-	if result.FirstRelease {
+	case notesSourceEmpty:
 		return "", nil
+	case notesSourceBuiltin:
+		return o.builtinReleaseNotes(result)
 	}
 	return o.GithubClient.GenerateReleaseNotes(ctx, o.repoOwner(), o.repoName(), result.ReleaseTag, result.PreviousRef)
 }
 
+// builtinReleaseNotes renders the pulls in the release with NotesTemplate or the default template.
+func (o *Runner) builtinReleaseNotes(result *Result) (string, error) {
+	tmpl := o.NotesTemplate
+	if tmpl == nil {
+		var err error
+		tmpl, err = parseNotesTemplate("")
+		if err != nil {
+			return "", err
+		}
+	}
+	return renderReleaseNotes(tmpl, newReleaseNotesData(o.Repo, result, o.pulls))
+}
+
 // shouldCreateTag returns true if a tag should be created.
 func (o *Runner) shouldCreateTag(ctx context.Context) bool {
+	return o.skipTagReason(ctx) == ""
+}
+
+// skipTagReason returns why no tag should be created or "" when one should be.
+func (o *Runner) skipTagReason(ctx context.Context) string {
 	// only when --create-tag or --create-release is set
 	if !cmp.Or(o.CreateTag, o.CreateRelease) {
-		return false
+		return "neither --create-tag nor --create-release is set"
 	}
 	// never create a tag if --check-pr is set
 	if o.CheckPR != 0 {
-		return false
+		return "--check-pr is set"
 	}
 	// only allowed refs
-	return o.isAllowedRef(ctx, o.Ref, o.ReleaseRefs)
+	if !o.isAllowedRef(ctx, o.Ref, o.ReleaseRefs) {
+		return fmt.Sprintf("%s doesn't match --release-ref", o.Ref)
+	}
+	return ""
 }
 
 func (o *Runner) runCmd(ctx context.Context, opts *runCmdOpts, command string, args ...string) (string, error) {
@@ -288,11 +393,16 @@ func (o *Runner) runCmd(ctx context.Context, opts *runCmdOpts, command string, a
 	return runCmd(ctx, opts, command, args...)
 }
 
+// run makes the release. The result is returned with the error when a step after publishing the release or tag fails
+// so that its outputs can still be written.
 func (o *Runner) run(ctx context.Context) (_ *Result, errOut error) {
 	if o.ran {
 		panic("Runner.run called multiple times, this is not allowed")
 	}
 	o.ran = true
+	if len(o.Components) > 0 {
+		return o.eachComponent(ctx, (*Runner).run)
+	}
 	slog.Debug("starting run")
 	defer func() {
 		if errOut != nil {
@@ -321,6 +431,29 @@ func (o *Runner) run(ctx context.Context) (_ *Result, errOut error) {
 		return nil, err
 	}
 
+	releasePRPending, err := o.releasePRPending(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if releasePRPending {
+		err = o.checkGoModule(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+		err = o.checkGoAPI(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !o.shouldCreateTag(ctx) {
+			return result, nil
+		}
+		err = o.updateReleasePR(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
 	err = os.MkdirAll(o.assetsDir(), 0o700)
 	if err != nil {
 		return nil, err
@@ -334,26 +467,61 @@ func (o *Runner) run(ctx context.Context) (_ *Result, errOut error) {
 	if result.PreTagHookAborted {
 		return result, nil
 	}
+	err = o.checkGoModule(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	err = o.checkGoAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if result.ReleaseVersion == nil || !o.shouldCreateTag(ctx) {
 		return result, nil
 	}
 
-	err = o.tagRelease(ctx, result.ReleaseTag)
+	committed, err := o.commitReleaseFiles(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.tagRelease(ctx, result)
 	if err != nil {
 		return nil, err
 	}
 	cancelTagDelete := o.addErrCleanup(func() error {
-		_, e := o.runCmd(ctx, nil, "git", "push", o.PushRemote, "--delete", result.ReleaseTag)
-		return e
+		return o.deleteRemoteTag(ctx, result.ReleaseTag)
 	})
 
 	result.CreatedTag = true
 
+	err = o.runPostTagHook(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+
 	if o.CreateRelease {
-		err = o.createRelease(ctx, result, cancelTagDelete)
+		var published bool
+		published, err = o.createRelease(ctx, result, cancelTagDelete)
 		if err != nil {
+			if published {
+				return result, err
+			}
 			return nil, err
 		}
+	} else {
+		if committed {
+			// createRelease pushes the target for releases. Without a release, the commit still needs to be pushed.
+			err = o.pushTarget(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// the tag is the release, so it stays even if the aliases can't be moved
+		cancelTagDelete()
+		err = o.moveAliasTags(ctx, result)
+		if err != nil {
+			return result, fmt.Errorf("moving alias tags after %s was tagged: %w", result.ReleaseTag, err)
+		}
 	}
 
 	return result, nil
@@ -381,16 +549,18 @@ func (o *Runner) rejectShallowCheckout(ctx context.Context) error {
 // cleanups (DeleteRelease and the tag delete) are cancelled, because the
 // server may have processed the publish even if the client saw an error, and
 // deleting either side in that state permanently reserves the tag name.
-func (o *Runner) createRelease(ctx context.Context, result *Result, cancelTagDelete func()) error {
+// published reports that point was reached, so the release and tag are kept
+// even when an error is returned.
+func (o *Runner) createRelease(ctx context.Context, result *Result, cancelTagDelete func()) (published bool, _ error) {
 	releaseNotes, err := o.getReleaseNotes(ctx, result)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	prerelease := result.ReleaseVersion.Prerelease() != ""
 	rel, err := o.GithubClient.CreateRelease(ctx, o.repoOwner(), o.repoName(), result.ReleaseTag, releaseNotes, prerelease)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	cancelDeleteRelease := o.addErrCleanup(func() error {
@@ -399,12 +569,17 @@ func (o *Runner) createRelease(ctx context.Context, result *Result, cancelTagDel
 
 	err = o.uploadAssets(ctx, rel.UploadURL)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	result.CreatedRelease = true
 	if o.Draft {
-		return nil
+		return false, nil
+	}
+
+	makeLatest, err := o.makeLatest(ctx, result)
+	if err != nil {
+		return false, err
 	}
 
 	// Push the target before publishing so the release stays a draft (and no
@@ -412,7 +587,7 @@ func (o *Runner) createRelease(ctx context.Context, result *Result, cancelTagDel
 	// to a possibly-protected branch — has succeeded.
 	err = o.pushTarget(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Publish boundary: from here on, neither the release nor the tag may be
@@ -421,7 +596,20 @@ func (o *Runner) createRelease(ctx context.Context, result *Result, cancelTagDel
 	cancelDeleteRelease()
 	cancelTagDelete()
 
-	return o.GithubClient.PublishRelease(ctx, o.repoOwner(), o.repoName(), o.MakeLatest, rel.ID)
+	pub, err := o.GithubClient.PublishRelease(ctx, o.repoOwner(), o.repoName(), makeLatest, rel.ID)
+	if err != nil {
+		return true, err
+	}
+	result.ReleaseURL = pub.URL
+	err = o.moveAliasTags(ctx, result)
+	if err != nil {
+		return true, fmt.Errorf("moving alias tags after %s was released: %w", result.ReleaseTag, err)
+	}
+	return true, o.runPostReleaseHook(ctx, result, &github.RepoRelease{
+		ID:        rel.ID,
+		UploadURL: rel.UploadURL,
+		URL:       pub.URL,
+	})
 }
 
 func (o *Runner) uploadAssets(ctx context.Context, uploadURL string) error {
@@ -441,24 +629,46 @@ func (o *Runner) uploadAssets(ctx context.Context, uploadURL string) error {
 }
 
 func (o *Runner) pushTarget(ctx context.Context) error {
+	var err error
+	if o.APIPush {
+		err = o.apiPushTarget(ctx)
+	} else {
+		err = o.gitPushTarget(ctx)
+	}
+	if err == nil && o.cancelTargetReset != nil {
+		o.cancelTargetReset()
+	}
+	return err
+}
+
+func (o *Runner) gitPushTarget(ctx context.Context) error {
+	target, branch, err := o.targetBranch(ctx)
+	if err != nil || branch == "" {
+		return err
+	}
+	_, err = o.runCmd(ctx, nil, "git", "push", o.PushRemote, target)
+	return err
+}
+
+// targetBranch returns the release target and the full name of the branch it refers to. branch is empty when the
+// target isn't a branch. Only branches are pushed.
+func (o *Runner) targetBranch(ctx context.Context) (target, branch string, _ error) {
 	target, err := o.getReleaseTarget()
 	if err != nil {
-		return err
+		return "", "", err
 	}
 	ref, err := o.runCmd(ctx, nil, "git", "rev-parse", "--verify", "--symbolic-full-name", target)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 	if !strings.HasPrefix(ref, "refs/heads/") {
-		// only push branches
-		return nil
+		return target, "", nil
 	}
-	_, err = o.runCmd(ctx, nil, "git", "push", o.PushRemote, target)
-	return err
+	return target, ref, nil
 }
 
-func (o *Runner) tagRelease(ctx context.Context, releaseTag string) error {
-	exists, err := localTagExists(ctx, o.CheckoutDir, releaseTag)
+func (o *Runner) tagRelease(ctx context.Context, result *Result) error {
+	exists, err := localTagExists(ctx, o.CheckoutDir, result.ReleaseTag)
 	if err != nil {
 		return err
 	}
@@ -469,12 +679,24 @@ func (o *Runner) tagRelease(ctx context.Context, releaseTag string) error {
 			return err
 		}
 
-		_, err = o.runCmd(ctx, nil, "git", "tag", releaseTag, target)
+		err = o.createTag(ctx, result, target)
 		if err != nil {
 			return err
 		}
 	}
-	_, err = o.runCmd(ctx, nil, "git", "push", o.PushRemote, releaseTag)
+	if o.APIPush {
+		return o.apiPushTag(ctx, result.ReleaseTag)
+	}
+	_, err = o.runCmd(ctx, nil, "git", "push", o.PushRemote, result.ReleaseTag)
+	return err
+}
+
+// deleteRemoteTag deletes a tag pushed by tagRelease.
+func (o *Runner) deleteRemoteTag(ctx context.Context, tag string) error {
+	if o.APIPush {
+		return o.apiDeleteTag(ctx, tag)
+	}
+	_, err := o.runCmd(ctx, nil, "git", "push", o.PushRemote, "--delete", tag)
 	return err
 }
 
@@ -482,61 +704,157 @@ func (o *Runner) runPreTagHook(ctx context.Context, result Result) (Result, erro
 	if o.PreTagHook == "" {
 		return result, nil
 	}
+	env, err := o.hookEnv(ctx, &result)
+	if err != nil {
+		return result, err
+	}
+	output, stderr, err := o.runHook(ctx, o.PreTagHook, env)
+	result.PreTagHookOutput = output
+	result.PrereleaseHookOutput = output
+	if err != nil {
+		const exitCodeAborted = 10
+		exitErr := asExitErr(err)
+		if exitErr != nil {
+			if exitErr.ExitCode() == exitCodeAborted {
+				slog.Debug("pre-tag hook aborted")
+				result.PreTagHookAborted = true
+				result.PrereleaseHookAborted = true
+				return result, nil
+			}
+			err = exitErr
+		}
+		slog.Error(
+			"pre-tag hook failed",
+			slog.Any("err", err),
+			slog.String("stdout", output),
+			slog.String("stderr", stderr),
+		)
+		return result, err
+	}
+	return result, nil
+}
+
+// runPostTagHook runs --post-tag-hook after the tag is pushed and before the release is created, so it can still
+// write assets and release notes. A failure deletes the tag like any other error after tagging.
+func (o *Runner) runPostTagHook(ctx context.Context, result *Result) error {
+	if o.PostTagHook == "" {
+		return nil
+	}
+	env, err := o.hookEnv(ctx, result)
+	if err != nil {
+		return err
+	}
+	output, stderr, err := o.runHook(ctx, o.PostTagHook, env)
+	result.PostTagHookOutput = output
+	if err != nil {
+		exitErr := asExitErr(err)
+		if exitErr != nil {
+			err = exitErr
+		}
+		slog.Error(
+			"post-tag hook failed",
+			slog.Any("err", err),
+			slog.String("stdout", output),
+			slog.String("stderr", stderr),
+		)
+		return fmt.Errorf("post-tag hook failed: %w", err)
+	}
+	return nil
+}
+
+// runPostReleaseHook runs --post-release-hook after rel is published. It gets the pre-tag hook's environment plus
+// the release's ID and URLs. The release can't be undone at this point, so a failure only fails the run.
+func (o *Runner) runPostReleaseHook(ctx context.Context, result *Result, rel *github.RepoRelease) error {
+	if o.PostReleaseHook == "" {
+		return nil
+	}
+	env, err := o.hookEnv(ctx, result)
+	if err != nil {
+		return err
+	}
+	env["RELEASE_ID"] = strconv.FormatInt(rel.ID, 10)
+	env["RELEASE_URL"] = rel.URL
+	env["RELEASE_UPLOAD_URL"] = rel.UploadURL
+	output, stderr, err := o.runHook(ctx, o.PostReleaseHook, env)
+	result.PostReleaseHookOutput = output
+	if err != nil {
+		exitErr := asExitErr(err)
+		if exitErr != nil {
+			err = exitErr
+		}
+		slog.Error(
+			"post-release hook failed",
+			slog.Any("err", err),
+			slog.String("stdout", output),
+			slog.String("stderr", stderr),
+		)
+		return fmt.Errorf("post-release hook failed after %s was released: %w", result.ReleaseTag, err)
+	}
+	return nil
+}
+
+// hookEnv returns the environment variables hooks get for result. It writes result's pulls to RELEASE_PULLS_FILE.
+// GITHUB_TOKEN comes from GithubTokenFunc when it is set, so a GitHub App installation token is current.
+func (o *Runner) hookEnv(ctx context.Context, result *Result) (map[string]string, error) {
+	githubToken := o.GithubToken
+	if o.GithubTokenFunc != nil {
+		var err error
+		githubToken, err = o.GithubTokenFunc(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
 	releaseVersion := ""
 	if result.ReleaseVersion != nil {
 		releaseVersion = result.ReleaseVersion.String()
 	}
-	env := map[string]string{
+	pulls := result.Pulls
+	if pulls == nil {
+		pulls = ghPulls{}
+	}
+	content, err := json.Marshal(pulls)
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(o.releasePullsFile(), content, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
 		"RELEASE_TAG":             result.ReleaseTag,
 		"PREVIOUS_VERSION":        result.PreviousVersion,
 		"PREVIOUS_REF":            result.PreviousRef,
 		"PREVIOUS_STABLE_VERSION": result.PreviousStableVersion,
 		"PREVIOUS_STABLE_REF":     result.PreviousStableRef,
 		"FIRST_RELEASE":           strconv.FormatBool(result.FirstRelease),
-		"GITHUB_TOKEN":            o.GithubToken,
+		"GITHUB_TOKEN":            githubToken,
 		"RELEASE_NOTES_FILE":      o.releaseNotesFile(),
 		"RELEASE_TARGET":          o.releaseTargetFile(),
 		"ASSETS_DIR":              o.assetsDir(),
 		"RELEASE_VERSION":         releaseVersion,
-	}
+		"RELEASE_COMPONENT":       o.Component,
+		"RELEASE_PULLS_FILE":      o.releasePullsFile(),
+	}, nil
+}
+
+// runHook runs a hook command with env. Its stdout and stderr are copied to o.Stdout and o.Stderr.
+func (o *Runner) runHook(ctx context.Context, hook string, env map[string]string) (stdout, stderr string, _ error) {
 	var stdoutBuf, stderrBuf bytes.Buffer
-	var stdout, stderr io.Writer
-	stdout = &stdoutBuf
+	var stdoutW, stderrW io.Writer
+	stdoutW = &stdoutBuf
 	if o.Stdout != nil {
-		stdout = io.MultiWriter(o.Stdout, &stdoutBuf)
+		stdoutW = io.MultiWriter(o.Stdout, &stdoutBuf)
 	}
-	stderr = &stderrBuf
+	stderrW = &stderrBuf
 	if o.Stderr != nil {
-		stderr = io.MultiWriter(o.Stderr, &stderrBuf)
+		stderrW = io.MultiWriter(o.Stderr, &stderrBuf)
 	}
 	_, err := o.runCmd(ctx, &runCmdOpts{
-		stdout: stdout,
-		stderr: stderr,
+		stdout: stdoutW,
+		stderr: stderrW,
 		env:    env,
-	}, "sh", "-c", o.PreTagHook)
-	result.PreTagHookOutput = stdoutBuf.String()
-	result.PrereleaseHookOutput = stdoutBuf.String()
-	if err != nil {
-		const exitCodeAborted = 10
-		exitErr := asExitErr(err)
-		if exitErr != nil {
-			if exitErr.ExitCode() == exitCodeAborted {
-				slog.Debug("pre-tag hook aborted")
-				result.PreTagHookAborted = true
-				result.PrereleaseHookAborted = true
-				return result, nil
-			}
-			err = exitErr
-		}
-		slog.Error(
-			"pre-tag hook failed",
-			slog.Any("err", err),
-			slog.String("stdout", stdoutBuf.String()),
-			slog.String("stderr", stderrBuf.String()),
-		)
-		return result, err
-	}
-	return result, nil
+	}, "sh", "-c", hook)
+	return stdoutBuf.String(), stderrBuf.String(), err
 }
 
 // isAllowedRef checks if the given commitish is one ot the allowed refs. Returns true when
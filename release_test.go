@@ -9,11 +9,13 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/willabides/release-train/v3/internal/github"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
 	"github.com/willabides/release-train/v3/internal/mocks"
 	"go.uber.org/mock/gomock"
 )
@@ -25,6 +27,90 @@ func mustRunCmd(t *testing.T, dir, name string, args ...string) string {
 	return out
 }
 
+// setupTestRepo creates an origin repository with a "first" commit tagged tag and a "feat: add foo" commit on main and
+// returns it with a clone. script runs in origin between the two commits.
+func setupTestRepo(t *testing.T, tag, script string) (origin, clone string) {
+	t.Helper()
+	origin = t.TempDir()
+	clone = t.TempDir()
+	mustRunCmd(t, origin, "sh", "-c", `
+git init -q -b main
+git config user.name 'tester'
+git config user.email 'tester@example.com'
+git commit -q --allow-empty -m "first"
+git tag `+tag+`
+`+script+`
+git commit -q --allow-empty -m "feat: add foo"
+`)
+	mustRunCmd(t, clone, "git", "clone", "-q", origin, ".")
+	mustRunCmd(t, clone, "git", "config", "user.name", "tester")
+	mustRunCmd(t, clone, "git", "config", "user.email", "tester@example.com")
+	return origin, clone
+}
+
+// newTestRunner returns a Runner that tags main in clone from Conventional Commits without the GitHub API.
+func newTestRunner(t *testing.T, clone string) *Runner {
+	t.Helper()
+	return &Runner{
+		CheckoutDir: clone,
+		Ref:         "main",
+		TagPrefix:   "v",
+		Repo:        "orgName/repoName",
+		PushRemote:  "origin",
+		TempDir:     t.TempDir(),
+		GitOnly:     true,
+		LevelSource: levelSourceConventional,
+		CreateTag:   true,
+	}
+}
+
+// githubFixture is a repository with a fake GitHub that knows about its commits.
+type githubFixture struct {
+	origin, clone string
+	// feature is the merge commit of PR #1
+	feature string
+	server  *githubtest.Server
+	repo    *githubtest.Repo
+	client  *github.Client
+}
+
+// setupGithubRepo creates an origin with a "first" commit tagged v1.0.0 followed by the merge of PR #1, "add foo",
+// labeled semver:minor. script runs in origin before the first commit, and what it adds is part of that commit. The
+// clone has no git identity.
+func setupGithubRepo(t *testing.T, script string) *githubFixture {
+	t.Helper()
+	f := &githubFixture{origin: t.TempDir(), clone: t.TempDir()}
+	mustRunCmd(t, f.origin, "sh", "-c", `
+git init -q -b main
+git config user.name 'tester'
+git config user.email 'tester@example.com'
+`+script+`
+git add -A
+git commit -q --allow-empty -m "first"
+git tag v1.0.0
+git commit -q --allow-empty -m "add foo (#1)"
+`)
+	mustRunCmd(t, f.clone, "git", "clone", "-q", f.origin, ".")
+	first := mustRunCmd(t, f.origin, "git", "rev-parse", "v1.0.0")
+	f.feature = mustRunCmd(t, f.origin, "git", "rev-parse", "HEAD")
+	f.server = githubtest.NewServer("token")
+	t.Cleanup(f.server.Close)
+	f.repo = f.server.AddRepo("orgName", "repoName", first, f.feature)
+	f.repo.Refs["refs/heads/main"] = f.feature
+	f.repo.Refs["refs/tags/v1.0.0"] = first
+	f.server.AddPull(f.repo, githubtest.Pull{
+		Number:         1,
+		Title:          "add foo",
+		Labels:         []string{labelMinor},
+		Merged:         true,
+		MergeCommitSha: f.feature,
+	})
+	var err error
+	f.client, err = github.NewClient(f.server.APIURL, "token", "release-train/test")
+	require.NoError(t, err)
+	return f
+}
+
 func Test_releaseRunner_run(t *testing.T) {
 	t.Parallel()
 	mergeSha := "4aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
@@ -87,16 +173,13 @@ git tag head
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 2,
-				Commits: []string{repos.taggedCommits["fourth"], repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 1}, nil,
 		)
-		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fourth"]).Return(
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
 			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{"MinorAlias"}}}, nil,
 		)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
@@ -128,7 +211,7 @@ git tag head
 				return nil
 			},
 		).AnyTimes()
-		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "true", int64(1)).Return(nil)
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "true", int64(1)).Return(&github.RepoRelease{ID: 1}, nil)
 
 		preHook := `
 #!/bin/sh
@@ -187,6 +270,7 @@ echo bar > "$ASSETS_DIR/bar.txt"
 			ReleaseVersion:        semver.MustParse("2.1.0"),
 			ReleaseTag:            "v2.1.0",
 			ChangeLevel:           changeLevelMinor,
+			Pulls:                 ghPulls{{Number: 1, LevelLabels: []string{"MinorAlias"}, ChangeLevel: changeLevelMinor}},
 			CreatedTag:            true,
 			CreatedRelease:        true,
 			PrereleaseHookOutput:  "hello to my friends reading stdout\n",
@@ -211,7 +295,7 @@ echo bar > "$ASSETS_DIR/bar.txt"
 				UploadURL: "localhost",
 			}, nil,
 		)
-		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(nil)
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(&github.RepoRelease{ID: 1}, nil)
 		runner := Runner{
 			CheckoutDir:   repos.clone,
 			Ref:           repos.taggedCommits["third"],
@@ -240,16 +324,13 @@ echo bar > "$ASSETS_DIR/bar.txt"
 		repos := setupGit(t)
 
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 2,
-				Commits: []string{repos.taggedCommits["fourth"], repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 2}, nil,
 		)
-		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fourth"]).Return(
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
 			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
 		)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
@@ -299,6 +380,7 @@ echo "$(git rev-parse HEAD)" > "$RELEASE_TARGET"
 			PreviousStableRef:     "v2.0.0",
 			PreviousStableVersion: "2.0.0",
 			ChangeLevel:           changeLevelMinor,
+			Pulls:                 ghPulls{{Number: 1, LevelLabels: []string{labelMinor}, ChangeLevel: changeLevelMinor}},
 			CreatedTag:            true,
 			CreatedRelease:        false,
 		}, got)
@@ -313,16 +395,13 @@ echo "$(git rev-parse HEAD)" > "$RELEASE_TARGET"
 		repos := setupGit(t)
 
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 2,
-				Commits: []string{repos.taggedCommits["fourth"], repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 2}, nil,
 		)
-		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fourth"]).Return(
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
 			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
 		)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
@@ -338,6 +417,7 @@ echo "$(git rev-parse HEAD)" > "$RELEASE_TARGET"
 			GithubClient: githubClient,
 			CreateTag:    true,
 			PreTagHook:   preHook,
+			TempDir:      t.TempDir(),
 		}
 		got, err := runner.run(ctx)
 		require.NoError(t, err)
@@ -350,6 +430,7 @@ echo "$(git rev-parse HEAD)" > "$RELEASE_TARGET"
 			PreviousStableRef:     "v2.0.0",
 			PreviousStableVersion: "2.0.0",
 			ChangeLevel:           changeLevelMinor,
+			Pulls:                 ghPulls{{Number: 1, LevelLabels: []string{labelMinor}, ChangeLevel: changeLevelMinor}},
 			CreatedTag:            false,
 			CreatedRelease:        false,
 			PrereleaseHookOutput:  "aborting\n",
@@ -365,16 +446,13 @@ echo "$(git rev-parse HEAD)" > "$RELEASE_TARGET"
 		repos := setupGit(t)
 
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 2,
-				Commits: []string{repos.taggedCommits["fourth"], repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 2}, nil,
 		)
-		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fourth"]).Return(
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
 			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
 		)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
@@ -398,6 +476,7 @@ exit 1
 			PushRemote:   "origin",
 			GithubClient: githubClient,
 			PreTagHook:   preHook,
+			TempDir:      t.TempDir(),
 		}
 		_, err := runner.run(ctx)
 		require.EqualError(t, err, "exit status 1")
@@ -410,16 +489,13 @@ exit 1
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 0,
-				Commits: []string{repos.taggedCommits["v2.0.0"], repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
-		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["v2.0.0"]).Return(
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
 			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
 		)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
@@ -434,7 +510,7 @@ exit 1
 				UploadURL: "localhost",
 			}, nil,
 		)
-		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(nil)
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(&github.RepoRelease{ID: 1}, nil)
 
 		runner := Runner{
 			CheckoutDir:   repos.clone,
@@ -456,11 +532,324 @@ exit 1
 			ReleaseTag:            "v2.1.0",
 			ReleaseVersion:        semver.MustParse("2.1.0"),
 			ChangeLevel:           changeLevelMinor,
+			Pulls:                 ghPulls{{Number: 1, LevelLabels: []string{labelMinor}, ChangeLevel: changeLevelMinor}},
 			CreatedTag:            true,
 			CreatedRelease:        true,
 		}, got)
 	})
 
+	t.Run("post-release hook", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		repos := setupGit(t)
+		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
+		)
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 0}, nil,
+		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
+			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
+		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
+			[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
+		)
+		githubClient.EXPECT().GenerateReleaseNotes(gomock.Any(), "orgName", "repoName", "v2.1.0", "v2.0.0").Return(
+			"release notes", nil,
+		)
+		githubClient.EXPECT().CreateRelease(gomock.Any(), "orgName", "repoName", "v2.1.0", "release notes", false).Return(
+			&github.RepoRelease{
+				ID:        1,
+				UploadURL: "localhost",
+			}, nil,
+		)
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(
+			&github.RepoRelease{ID: 1, URL: "https://example.com/releases/v2.1.0"}, nil,
+		)
+
+		runner := Runner{
+			CheckoutDir:     repos.clone,
+			Ref:             repos.taggedCommits["head"],
+			TagPrefix:       "v",
+			Repo:            "orgName/repoName",
+			PushRemote:      "origin",
+			TempDir:         t.TempDir(),
+			GithubClient:    githubClient,
+			CreateRelease:   true,
+			PostReleaseHook: `echo "$RELEASE_TAG $RELEASE_ID $RELEASE_URL $RELEASE_UPLOAD_URL"`,
+		}
+		got, err := runner.run(ctx)
+		require.NoError(t, err)
+		require.True(t, got.CreatedRelease)
+		require.Equal(t, "https://example.com/releases/v2.1.0", got.ReleaseURL)
+		require.Equal(t, "v2.1.0 1 https://example.com/releases/v2.1.0 localhost\n", got.PostReleaseHookOutput)
+	})
+
+	t.Run("post-release hook failure keeps the release", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		repos := setupGit(t)
+		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
+		)
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 0}, nil,
+		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
+			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelMinor}}}, nil,
+		)
+		githubClient.EXPECT().GenerateReleaseNotes(gomock.Any(), "orgName", "repoName", "v2.1.0", "v2.0.0").Return(
+			"release notes", nil,
+		)
+		githubClient.EXPECT().CreateRelease(gomock.Any(), "orgName", "repoName", "v2.1.0", "release notes", false).Return(
+			&github.RepoRelease{
+				ID:        1,
+				UploadURL: "localhost",
+			}, nil,
+		)
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(&github.RepoRelease{ID: 1}, nil)
+		// No DeleteRelease expectation: the published release must stay.
+
+		runner := Runner{
+			CheckoutDir:     repos.clone,
+			Ref:             repos.taggedCommits["head"],
+			TagPrefix:       "v",
+			Repo:            "orgName/repoName",
+			PushRemote:      "origin",
+			TempDir:         t.TempDir(),
+			GithubClient:    githubClient,
+			CreateRelease:   true,
+			PostReleaseHook: "exit 1",
+		}
+		got, err := runner.run(ctx)
+		require.EqualError(t, err, "post-release hook failed after v2.1.0 was released: exit status 1")
+		// the outputs are still written for the published release
+		require.NotNil(t, got)
+		require.True(t, got.CreatedTag)
+		require.True(t, got.CreatedRelease)
+		require.Equal(t, "v2.1.0", got.ReleaseTag)
+		ok, err := localTagExists(ctx, repos.origin, "v2.1.0")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("builtin release notes", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		repos := setupGit(t)
+		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
+		)
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 1}, nil,
+		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
+			[]github.BasePull{{
+				Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor},
+				Title: "add foo", Author: "alice", URL: "https://github.com/orgName/repoName/pull/1",
+			}}, nil,
+		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
+			[]github.BasePull{{
+				Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelPatch},
+				Title: "fix bar", Author: "bob", URL: "https://github.com/orgName/repoName/pull/2",
+			}}, nil,
+		)
+		notes := `### Features
+
+- add foo ([#1](https://github.com/orgName/repoName/pull/1)) by @alice
+
+### Fixes
+
+- fix bar ([#2](https://github.com/orgName/repoName/pull/2)) by @bob`
+		githubClient.EXPECT().CreateRelease(gomock.Any(), "orgName", "repoName", "v2.1.0", notes, false).Return(
+			&github.RepoRelease{
+				ID:        1,
+				UploadURL: "localhost",
+			}, nil,
+		)
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(&github.RepoRelease{ID: 1}, nil)
+
+		runner := Runner{
+			CheckoutDir:   repos.clone,
+			Ref:           repos.taggedCommits["head"],
+			TagPrefix:     "v",
+			Repo:          "orgName/repoName",
+			PushRemote:    "origin",
+			GithubClient:  githubClient,
+			CreateRelease: true,
+			ReleaseNotes:  releaseNotesBuiltin,
+		}
+		got, err := runner.run(ctx)
+		require.NoError(t, err)
+		require.True(t, got.CreatedRelease)
+	})
+
+	t.Run("changelog", func(t *testing.T) {
+		t.Parallel()
+		now := func() time.Time { return time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC) }
+		changelog := `# Changelog
+
+## [Unreleased]
+
+## [2.0.0] - 2024-01-01
+
+- old
+`
+		wantChangelog := `# Changelog
+
+## [Unreleased]
+
+## [2.1.0] - 2024-05-06
+
+### Features
+
+- add foo (#1)
+
+## [2.0.0] - 2024-01-01
+
+- old
+`
+		setup := func(t *testing.T) (*gitRepos, *mocks.MockGithubClient) {
+			t.Helper()
+			repos := setupGit(t)
+			mustRunCmd(t, repos.origin, "git", "config", "receive.denyCurrentBranch", "ignore")
+			require.NoError(t, os.WriteFile(filepath.Join(repos.clone, "CHANGELOG.md"), []byte(changelog), 0o600))
+			mustRunCmd(t, repos.clone, "git", "add", "CHANGELOG.md")
+			mustRunCmd(t, repos.clone, "git", "-c", "user.name=tester", "-c", "user.email=tester", "commit", "-q", "-m", "add changelog")
+			head := mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD")
+			githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
+			githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", head, 0).Return(
+				&github.CommitComparison{AheadBy: 3}, nil,
+			)
+			githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
+			githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(nil, nil)
+			githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, head, 0).Return(
+				&github.CommitComparison{AheadBy: 1}, nil,
+			)
+			githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", head).Return(
+				[]github.BasePull{{Number: 1, MergeCommitSha: mergeSha, Labels: []string{labelMinor}, Title: "add foo"}}, nil,
+			)
+			return repos, githubClient
+		}
+		checkTag := func(t *testing.T, dir, parent string) {
+			t.Helper()
+			require.Equal(t, parent, mustRunCmd(t, dir, "git", "rev-parse", "v2.1.0^"))
+			require.Equal(t, "Release v2.1.0", mustRunCmd(t, dir, "git", "log", "-1", "--format=%s", "v2.1.0"))
+			require.Equal(t, strings.TrimSpace(wantChangelog), mustRunCmd(t, dir, "git", "show", "v2.1.0:CHANGELOG.md"))
+		}
+
+		t.Run("branch", func(t *testing.T) {
+			t.Parallel()
+			repos, githubClient := setup(t)
+			head := mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD")
+			branch := mustRunCmd(t, repos.clone, "git", "rev-parse", "--abbrev-ref", "HEAD")
+			runner := &Runner{
+				CheckoutDir:  repos.clone,
+				Ref:          "HEAD",
+				TagPrefix:    "v",
+				Repo:         "orgName/repoName",
+				PushRemote:   "origin",
+				GithubClient: githubClient,
+				CreateTag:    true,
+				Changelog:    "CHANGELOG.md",
+				TempDir:      t.TempDir(),
+				now:          now,
+			}
+			got, err := runner.run(t.Context())
+			require.NoError(t, err)
+			require.True(t, got.CreatedTag)
+			checkTag(t, repos.origin, head)
+			tagged := mustRunCmd(t, repos.origin, "git", "rev-parse", "v2.1.0^{commit}")
+			require.Equal(t, tagged, mustRunCmd(t, repos.origin, "git", "rev-parse", branch))
+			require.Equal(t, tagged, mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD"))
+			content, err := os.ReadFile(filepath.Join(repos.clone, "CHANGELOG.md"))
+			require.NoError(t, err)
+			require.Equal(t, wantChangelog, string(content))
+			require.Empty(t, mustRunCmd(t, repos.clone, "git", "status", "--porcelain"))
+		})
+
+		t.Run("failed release resets the branch", func(t *testing.T) {
+			t.Parallel()
+			repos, githubClient := setup(t)
+			githubClient.EXPECT().CreateRelease(gomock.Any(), "orgName", "repoName", "v2.1.0", gomock.Any(), false).Return(
+				nil, errors.New("create failed"),
+			)
+			head := mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD")
+			runner := &Runner{
+				CheckoutDir:   repos.clone,
+				Ref:           "HEAD",
+				TagPrefix:     "v",
+				Repo:          "orgName/repoName",
+				PushRemote:    "origin",
+				GithubClient:  githubClient,
+				CreateRelease: true,
+				ReleaseNotes:  releaseNotesBuiltin,
+				Changelog:     "CHANGELOG.md",
+				TempDir:       t.TempDir(),
+				now:           now,
+			}
+			_, err := runner.run(t.Context())
+			require.EqualError(t, err, "create failed")
+			require.Equal(t, head, mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD"))
+			content, err := os.ReadFile(filepath.Join(repos.clone, "CHANGELOG.md"))
+			require.NoError(t, err)
+			require.Equal(t, changelog, string(content))
+			require.Empty(t, mustRunCmd(t, repos.clone, "git", "status", "--porcelain"))
+			require.Empty(t, mustRunCmd(t, repos.origin, "git", "tag", "--list", "v2.1.0"))
+		})
+
+		t.Run("detached HEAD", func(t *testing.T) {
+			t.Parallel()
+			repos, githubClient := setup(t)
+			head := mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD")
+			mustRunCmd(t, repos.clone, "git", "checkout", "-q", "--detach")
+			runner := &Runner{
+				CheckoutDir:  repos.clone,
+				Ref:          "HEAD",
+				TagPrefix:    "v",
+				Repo:         "orgName/repoName",
+				PushRemote:   "origin",
+				GithubClient: githubClient,
+				CreateTag:    true,
+				Changelog:    "CHANGELOG.md",
+				TempDir:      t.TempDir(),
+				now:          now,
+			}
+			_, err := runner.run(t.Context())
+			require.ErrorContains(t, err, "cannot commit release files to HEAD because it is a detached HEAD")
+			require.Equal(t, head, mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD"))
+		})
+
+		t.Run("commit", func(t *testing.T) {
+			t.Parallel()
+			repos, githubClient := setup(t)
+			head := mustRunCmd(t, repos.clone, "git", "rev-parse", "HEAD")
+			branch := mustRunCmd(t, repos.clone, "git", "rev-parse", "--abbrev-ref", "HEAD")
+			runner := &Runner{
+				CheckoutDir:  repos.clone,
+				Ref:          head,
+				TagPrefix:    "v",
+				Repo:         "orgName/repoName",
+				PushRemote:   "origin",
+				GithubClient: githubClient,
+				CreateTag:    true,
+				Changelog:    "CHANGELOG.md",
+				TempDir:      t.TempDir(),
+				now:          now,
+			}
+			_, err := runner.run(t.Context())
+			require.NoError(t, err)
+			checkTag(t, repos.origin, head)
+			// the branch isn't moved
+			require.Equal(t, head, mustRunCmd(t, repos.clone, "git", "rev-parse", branch))
+		})
+	})
+
 	t.Run("shallow clone", func(t *testing.T) {
 		t.Parallel()
 		ctx := t.Context()
@@ -490,7 +879,7 @@ exit 1
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
 			nil, errors.New("api error"),
 		)
 		_, err := (&Runner{
@@ -508,15 +897,13 @@ exit 1
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
 			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelBreaking}}}, nil,
 		)
@@ -547,15 +934,13 @@ exit 1
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
 			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelBreaking}}}, nil,
 		)
@@ -600,15 +985,13 @@ echo bar > "$ASSETS_DIR/bar.txt"
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
 			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelBreaking}}}, nil,
 		)
@@ -630,6 +1013,7 @@ echo bar > "$ASSETS_DIR/bar.txt"
 			ReleaseVersion:        semver.MustParse("3.0.0"),
 			ReleaseTag:            "v3.0.0",
 			ChangeLevel:           changeLevelMajor,
+			Pulls:                 ghPulls{{Number: 2, LevelLabels: []string{labelBreaking}, ChangeLevel: changeLevelMajor}},
 		}, got)
 	})
 
@@ -638,16 +1022,14 @@ echo bar > "$ASSETS_DIR/bar.txt"
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{"fake"},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
-		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", "fake").Return(
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(nil, nil)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(
 			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelBreaking}}}, nil,
 		)
 		got, err := (&Runner{
@@ -670,6 +1052,7 @@ echo bar > "$ASSETS_DIR/bar.txt"
 			ReleaseVersion:        semver.MustParse("3.0.0"),
 			ReleaseTag:            "v3.0.0",
 			ChangeLevel:           changeLevelMajor,
+			Pulls:                 ghPulls{{Number: 2, LevelLabels: []string{labelBreaking}, ChangeLevel: changeLevelMajor}},
 		}, got)
 	})
 
@@ -678,11 +1061,8 @@ echo bar > "$ASSETS_DIR/bar.txt"
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v0.2.0", repos.taggedCommits["second"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["second"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v0.2.0", repos.taggedCommits["second"], 0).Return(
+			&github.CommitComparison{AheadBy: 1}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["second"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
@@ -709,6 +1089,7 @@ echo bar > "$ASSETS_DIR/bar.txt"
 			ReleaseVersion:        semver.MustParse("0.3.0"),
 			ReleaseTag:            "v0.3.0",
 			ChangeLevel:           changeLevelMinor,
+			Pulls:                 ghPulls{{Number: 2, LevelLabels: []string{labelBreaking}, ChangeLevel: changeLevelMajor}},
 		}, got)
 	})
 
@@ -732,11 +1113,8 @@ echo bar > "$ASSETS_DIR/bar.txt"
 		repos := setupGit(t)
 		mustRunCmd(t, repos.clone, "git", "tag", "v2.1.0-rc.1", "fifth")
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.1.0-rc.1", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.1.0-rc.1", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 1}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
@@ -762,6 +1140,7 @@ echo bar > "$ASSETS_DIR/bar.txt"
 			ReleaseVersion:        semver.MustParse("2.1.0-rc.2"),
 			ReleaseTag:            "v2.1.0-rc.2",
 			ChangeLevel:           changeLevelMinor,
+			Pulls:                 ghPulls{{Number: 2, LevelLabels: []string{labelMinor}, ChangeLevel: changeLevelMinor, HasPreLabel: true}},
 		}, got)
 	})
 
@@ -770,15 +1149,13 @@ echo bar > "$ASSETS_DIR/bar.txt"
 		ctx := t.Context()
 		repos := setupGit(t)
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
 			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelBreaking}}}, nil,
 		)
@@ -834,15 +1211,13 @@ echo "$current_branch" > "$RELEASE_TARGET"
 		// succeeds and we reach PublishRelease.
 		mustRunCmd(t, repos.origin, "git", "config", "receive.denyCurrentBranch", "ignore")
 		githubClient := mocks.NewMockGithubClient(gomock.NewController(t))
-		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], -1).Return(
-			&github.CommitComparison{
-				AheadBy: 1,
-				Commits: []string{repos.taggedCommits["head"]},
-			}, nil,
+		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", "v2.0.0", repos.taggedCommits["head"], 0).Return(
+			&github.CommitComparison{AheadBy: 2}, nil,
 		)
 		githubClient.EXPECT().CompareCommits(gomock.Any(), "orgName", "repoName", mergeSha, repos.taggedCommits["head"], 0).Return(
 			&github.CommitComparison{AheadBy: 0}, nil,
 		)
+		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["fifth"]).Return(nil, nil)
 		githubClient.EXPECT().ListMergedPullsForCommit(gomock.Any(), "orgName", "repoName", repos.taggedCommits["head"]).Return(
 			[]github.BasePull{{Number: 2, MergeCommitSha: mergeSha, Labels: []string{labelBreaking}}}, nil,
 		)
@@ -855,7 +1230,7 @@ echo "$current_branch" > "$RELEASE_TARGET"
 				UploadURL: "localhost",
 			}, nil,
 		)
-		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(errors.New("publish failed"))
+		githubClient.EXPECT().PublishRelease(gomock.Any(), "orgName", "repoName", "", int64(1)).Return(nil, errors.New("publish failed"))
 		// No DeleteRelease expectation: once PublishRelease has been attempted
 		// the cleanup must not delete the release. The mock controller fails
 		// the test if DeleteRelease is invoked.
@@ -888,3 +1263,68 @@ echo "$current_branch" > "$RELEASE_TARGET"
 		require.True(t, ok, "tag must not be deleted once PublishRelease has been attempted")
 	})
 }
+
+func TestRunner_postTagHook(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (origin, clone string) {
+		t.Helper()
+		return setupTestRepo(t, "v1.0.0", "")
+	}
+
+	newRunner := func(t *testing.T, clone, hook string) *Runner {
+		t.Helper()
+		runner := newTestRunner(t, clone)
+		runner.PostTagHook = hook
+		return runner
+	}
+
+	t.Run("runs on the tagged commit", func(t *testing.T) {
+		t.Parallel()
+		_, clone := setup(t)
+		runner := newRunner(t, clone, `git describe --tags --exact-match "$RELEASE_TAG"; echo foo > "$ASSETS_DIR/foo.txt"`)
+		got, err := runner.run(t.Context())
+		require.NoError(t, err)
+		require.True(t, got.CreatedTag)
+		require.Equal(t, "v1.1.0\n", got.PostTagHookOutput)
+		require.FileExists(t, filepath.Join(runner.assetsDir(), "foo.txt"))
+	})
+
+	t.Run("gets the release's pulls", func(t *testing.T) {
+		t.Parallel()
+		_, clone := setup(t)
+		head := mustRunCmd(t, clone, "git", "rev-parse", "HEAD")
+		got, err := newRunner(t, clone, `cat "$RELEASE_PULLS_FILE"`).run(t.Context())
+		require.NoError(t, err)
+		require.JSONEq(t,
+			`[{"commit": "`+head+`", "title": "feat: add foo", "labels": ["semver:minor"], "change_level": "minor"}]`,
+			got.PostTagHookOutput,
+		)
+	})
+
+	t.Run("gets the current GitHub token", func(t *testing.T) {
+		t.Parallel()
+		_, clone := setup(t)
+		runner := newRunner(t, clone, `echo "$GITHUB_TOKEN"`)
+		runner.PreTagHook = `echo "$GITHUB_TOKEN"`
+		calls := 0
+		runner.GithubTokenFunc = func(context.Context) (string, error) {
+			calls++
+			return fmt.Sprintf("token%d", calls), nil
+		}
+		got, err := runner.run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "token1\n", got.PreTagHookOutput)
+		require.Equal(t, "token2\n", got.PostTagHookOutput)
+	})
+
+	t.Run("failure deletes the tag", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t)
+		_, err := newRunner(t, clone, "exit 1").run(t.Context())
+		require.EqualError(t, err, "post-tag hook failed: exit status 1")
+		ok, err := localTagExists(t.Context(), origin, "v1.1.0")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// releaseFileChange is a file that is written in the release commit.
+type releaseFileChange struct {
+	// Path is relative to the checkout directory.
+	Path    string
+	Content []byte
+}
+
+// checkoutPath cleans a path that is relative to the checkout directory. It returns false for paths outside the
+// checkout directory.
+func checkoutPath(path string) (string, bool) {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, "../") {
+		return path, false
+	}
+	return path, true
+}
+
+// releaseFileChanges returns the files that need to change for the release.
+func (o *Runner) releaseFileChanges(ctx context.Context, result *Result) ([]releaseFileChange, error) {
+	var changes []releaseFileChange
+	if o.Changelog != "" {
+		change, err := o.changelogChange(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+	return o.versionFileChanges(ctx, result, changes)
+}
+
+// versionFileChanges adds the version file updates to changes. Files that are updated more than once, or are also the
+// changelog, get a single change with every update applied. Files that already have the release version are left out.
+func (o *Runner) versionFileChanges(ctx context.Context, result *Result, changes []releaseFileChange) ([]releaseFileChange, error) {
+	if len(o.VersionFiles) == 0 {
+		return changes, nil
+	}
+	target, err := o.getReleaseTarget()
+	if err != nil {
+		return nil, err
+	}
+	version := result.ReleaseVersion.String()
+	original := map[string]string{}
+	for _, file := range o.VersionFiles {
+		idx := slices.IndexFunc(changes, func(change releaseFileChange) bool {
+			return change.Path == file.Path
+		})
+		if idx < 0 {
+			var content string
+			content, err = o.readTargetFile(ctx, target, file.Path)
+			if err != nil {
+				return nil, err
+			}
+			original[file.Path] = content
+			changes = append(changes, releaseFileChange{Path: file.Path, Content: []byte(content)})
+			idx = len(changes) - 1
+		}
+		var updated string
+		updated, err = file.update(string(changes[idx].Content), version)
+		if err != nil {
+			return nil, err
+		}
+		changes[idx].Content = []byte(updated)
+	}
+	return slices.DeleteFunc(changes, func(change releaseFileChange) bool {
+		content, ok := original[change.Path]
+		return ok && content == string(change.Content)
+	}), nil
+}
+
+// releaseCommitChanges returns the file changes for the release commit. There are none with --release-pr because the
+// merged release PR already made them.
+func (o *Runner) releaseCommitChanges(ctx context.Context, result *Result) ([]releaseFileChange, error) {
+	if o.ReleasePR && !result.FirstRelease {
+		return nil, nil
+	}
+	return o.releaseFileChanges(ctx, result)
+}
+
+// commitReleaseFiles commits the release's file changes onto the release target and points the release target at the
+// new commit. It returns false when there is nothing to commit.
+func (o *Runner) commitReleaseFiles(ctx context.Context, result *Result) (bool, error) {
+	changes, err := o.releaseCommitChanges(ctx, result)
+	if err != nil || len(changes) == 0 {
+		return false, err
+	}
+	tagExists, err := localTagExists(ctx, o.CheckoutDir, result.ReleaseTag)
+	if err != nil {
+		return false, err
+	}
+	if tagExists {
+		return false, fmt.Errorf("cannot commit release files because the pre-tag hook created tag %q", result.ReleaseTag)
+	}
+	err = o.commitFileChanges(ctx, "Release "+result.ReleaseTag, changes)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// commitFileChanges creates a commit on top of the release target with changes applied to the target's tree. The
+// working tree isn't used, so uncommitted changes are left alone.
+func (o *Runner) commitFileChanges(ctx context.Context, message string, changes []releaseFileChange) error {
+	target, err := o.getReleaseTarget()
+	if err != nil {
+		return err
+	}
+	parent, err := o.runCmd(ctx, nil, "git", "rev-parse", "--verify", target+"^{commit}")
+	if err != nil {
+		return err
+	}
+	indexFile, err := filepath.Abs(filepath.Join(o.TempDir, "release-index"))
+	if err != nil {
+		return err
+	}
+	indexEnv := map[string]string{"GIT_INDEX_FILE": indexFile}
+	_, err = o.runCmd(ctx, &runCmdOpts{env: indexEnv}, "git", "read-tree", parent)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.Path
+		var mode, blob string
+		mode, err = o.indexFileMode(ctx, indexEnv, change.Path)
+		if err != nil {
+			return err
+		}
+		blob, err = o.runCmd(ctx, &runCmdOpts{stdin: bytes.NewReader(change.Content)}, "git", "hash-object", "-w", "--stdin")
+		if err != nil {
+			return err
+		}
+		_, err = o.runCmd(ctx, &runCmdOpts{env: indexEnv},
+			"git", "update-index", "--add", "--cacheinfo", mode+","+blob+","+change.Path)
+		if err != nil {
+			return err
+		}
+	}
+	tree, err := o.runCmd(ctx, &runCmdOpts{env: indexEnv}, "git", "write-tree")
+	if err != nil {
+		return err
+	}
+	commitEnv, err := o.commitIdentityEnv(ctx)
+	if err != nil {
+		return err
+	}
+	commit, err := o.runCmd(ctx, &runCmdOpts{env: commitEnv}, "git", "commit-tree", tree, "-p", parent, "-m", message)
+	if err != nil {
+		return err
+	}
+	return o.advanceReleaseTarget(ctx, target, parent, commit, paths)
+}
+
+// indexFileMode returns the mode of path in the index or 100644 for new files.
+func (o *Runner) indexFileMode(ctx context.Context, indexEnv map[string]string, path string) (string, error) {
+	out, err := o.runCmd(ctx, &runCmdOpts{env: indexEnv}, "git", "ls-files", "--stage", "--", path)
+	if err != nil {
+		return "", err
+	}
+	mode, _, _ := strings.Cut(out, " ")
+	if mode == "" {
+		return "100644", nil
+	}
+	return mode, nil
+}
+
+// advanceReleaseTarget points the release target at commit. Branches are moved to commit so that pushTarget pushes
+// it, and are moved back to parent if the release fails before they are pushed. Other targets are replaced by writing
+// commit to the release target file. A detached HEAD is rejected because there is no branch to push the commit to.
+// When the target is checked out, paths are updated in the index and working tree to match.
+func (o *Runner) advanceReleaseTarget(ctx context.Context, target, parent, commit string, paths []string) error {
+	ref, err := o.runCmd(ctx, nil, "git", "rev-parse", "--verify", "--symbolic-full-name", target)
+	if err != nil {
+		return err
+	}
+	if ref == "HEAD" {
+		return fmt.Errorf(
+			"cannot commit release files to %s because it is a detached HEAD. Check out a branch or use a commit sha", target,
+		)
+	}
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		return os.WriteFile(o.releaseTargetFile(), []byte(commit+"\n"), 0o600)
+	}
+	headRef, err := o.runCmd(ctx, nil, "git", "rev-parse", "--symbolic-full-name", "HEAD")
+	if err != nil {
+		return err
+	}
+	_, err = o.runCmd(ctx, nil, "git", "update-ref", "--no-deref", "-m", "release-train", ref, commit, parent)
+	if err != nil {
+		return err
+	}
+	checkedOut := ref == headRef
+	o.cancelTargetReset = o.addErrCleanup(func() error {
+		if checkedOut {
+			// moves the branch and undoes the release commit's changes to the working tree
+			_, e := o.runCmd(ctx, nil, "git", "reset", "-q", "--keep", parent)
+			return e
+		}
+		_, e := o.runCmd(ctx, nil, "git", "update-ref", "--no-deref", "-m", "release-train", ref, parent, commit)
+		return e
+	})
+	if !checkedOut {
+		return nil
+	}
+	_, err = o.runCmd(ctx, nil, "git", append([]string{"checkout", "-q", commit, "--"}, paths...)...)
+	return err
+}
+
+// commitIdentityEnv returns environment variables that set the commit author and committer when git doesn't have a
+// user configured, which is common in CI.
+func (o *Runner) commitIdentityEnv(ctx context.Context) (map[string]string, error) {
+	_, err := o.runCmd(ctx, &runCmdOpts{noLog: true}, "git", "config", "--get", "user.email")
+	if err == nil {
+		return nil, nil //nolint:nilnil // no overrides needed
+	}
+	exitErr := asExitErr(err)
+	if exitErr == nil || exitErr.ExitCode() != 1 {
+		return nil, err
+	}
+	const (
+		name  = "github-actions[bot]"
+		email = "41898282+github-actions[bot]@users.noreply.github.com"
+	)
+	return map[string]string{
+		"GIT_AUTHOR_NAME":     name,
+		"GIT_AUTHOR_EMAIL":    email,
+		"GIT_COMMITTER_NAME":  name,
+		"GIT_COMMITTER_EMAIL": email,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/willabides/release-train/v3/internal/github"
+)
+
+// releasePRTrailer is the trailer on the release PR's commit and at the end of its description. It finds the merged
+// release PR when the release's pulls don't have labels, such as with conventional-commits.
+const (
+	releasePRTrailer      = "Release-Train"
+	releasePRTrailerValue = "release-pr"
+)
+
+// releasePRPending returns true when --release-pr should open or update the release PR instead of tagging. That is
+// the case until the release PR is merged. First releases are tagged directly because there are no commits to find
+// the release PR in.
+func (o *Runner) releasePRPending(ctx context.Context, result *Result) (bool, error) {
+	if !o.ReleasePR || result.FirstRelease || o.pulls.hasReleasePR() {
+		return false, nil
+	}
+	merged, err := o.releasePRCommitMerged(ctx, result)
+	return !merged, err
+}
+
+// releasePRCommitMerged returns true when a commit since the previous release has the release PR's trailer. The
+// trailer survives merge, rebase and squash merges that keep the commit message or use the PR description.
+func (o *Runner) releasePRCommitMerged(ctx context.Context, result *Result) (bool, error) {
+	out, err := o.runCmd(ctx, &runCmdOpts{noLog: true}, "git", "log",
+		"--format=%(trailers:key="+releasePRTrailer+",valueonly)", result.PreviousRef+".."+cmp.Or(o.Ref, "HEAD"))
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(strings.Fields(out), func(val string) bool {
+		return strings.EqualFold(val, releasePRTrailerValue)
+	}), nil
+}
+
+// releasePRBase returns the sha and branch name of the release target, which is the release PR's base.
+func (o *Runner) releasePRBase(ctx context.Context) (sha, branch string, _ error) {
+	target, ref, err := o.targetBranch(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if ref == "" {
+		return "", "", fmt.Errorf("--release-pr needs the release target to be a branch, but %q isn't one", target)
+	}
+	sha, err = o.runCmd(ctx, nil, "git", "rev-parse", "--verify", target+"^{commit}")
+	if err != nil {
+		return "", "", err
+	}
+	return sha, strings.TrimPrefix(ref, "refs/heads/"), nil
+}
+
+// updateReleasePR commits the release's file changes to the release PR branch and opens the release PR or updates
+// the open one. The branch is rebuilt from the release target every time, so it always has a single commit.
+func (o *Runner) updateReleasePR(ctx context.Context, result *Result) error {
+	client, ok := o.GithubClient.(ReleasePRClient)
+	if !ok {
+		return errors.New("--release-pr is only supported with GitHub")
+	}
+	parent, base, err := o.releasePRBase(ctx)
+	if err != nil {
+		return err
+	}
+	changes, err := o.releaseFileChanges(ctx, result)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return errors.New("--release-pr needs files to change in the release such as --changelog")
+	}
+	title := "Release " + result.ReleaseTag
+	commit := github.NewCommit{
+		Parent:  parent,
+		Message: title + "\n\n" + releasePRTrailer + ": " + releasePRTrailerValue,
+		Files:   make([]github.FileChange, len(changes)),
+	}
+	for i, change := range changes {
+		commit.Files[i] = github.FileChange{Path: change.Path, Content: change.Content}
+	}
+	sha, err := client.CreateCommit(ctx, o.repoOwner(), o.repoName(), &commit)
+	if err != nil {
+		return err
+	}
+	err = client.UpdateBranch(ctx, o.repoOwner(), o.repoName(), o.ReleasePRBranch, sha)
+	if err != nil {
+		return err
+	}
+	body, err := o.releasePRBody(result)
+	if err != nil {
+		return err
+	}
+	pull, err := client.FindOpenPullRequest(ctx, o.repoOwner(), o.repoName(), o.ReleasePRBranch, base)
+	if err != nil {
+		return err
+	}
+	if pull != nil {
+		err = client.UpdatePullRequest(ctx, o.repoOwner(), o.repoName(), pull.Number, title, body)
+		if err != nil {
+			return err
+		}
+	} else {
+		pull, err = client.CreatePullRequest(ctx, o.repoOwner(), o.repoName(), &github.NewPull{
+			Title:  title,
+			Body:   body,
+			Head:   o.ReleasePRBranch,
+			Base:   base,
+			Labels: []string{labelNone, labelReleasePR},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	result.ReleasePullRequest = pull.Number
+	return nil
+}
+
+// releasePRBody describes the release PR with the release's builtin notes.
+func (o *Runner) releasePRBody(result *Result) (string, error) {
+	notes, err := o.builtinReleaseNotes(result)
+	if err != nil {
+		return "", err
+	}
+	body := fmt.Sprintf("Merging this PR releases %s.\n", result.ReleaseTag)
+	if notes != "" {
+		body += "\n" + notes + "\n"
+	}
+	// squash merges can use the description as the commit message
+	body += "\n" + releasePRTrailer + ": " + releasePRTrailerValue + "\n"
+	return body, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/willabides/release-train/v3/internal/github/githubtest"
+)
+
+func TestRunner_releasePR(t *testing.T) {
+	t.Parallel()
+
+	newRunner := func(t *testing.T, f *githubFixture) *Runner {
+		t.Helper()
+		return &Runner{
+			CheckoutDir:     f.clone,
+			Ref:             "main",
+			TagPrefix:       "v",
+			Repo:            "orgName/repoName",
+			PushRemote:      "origin",
+			TempDir:         t.TempDir(),
+			GithubClient:    f.client,
+			CreateTag:       true,
+			Changelog:       "CHANGELOG.md",
+			ReleasePR:       true,
+			ReleasePRBranch: "release-train/release",
+			now:             func() time.Time { return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC) },
+		}
+	}
+
+	setup := func(t *testing.T) *githubFixture {
+		t.Helper()
+		return setupGithubRepo(t, `printf '# Changelog\n\n## [Unreleased]\n' > CHANGELOG.md`)
+	}
+
+	t.Run("opens and updates the release PR", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		head := mustRunCmd(t, f.clone, "git", "rev-parse", "HEAD")
+		for range 2 {
+			got, err := newRunner(t, f).run(t.Context())
+			require.NoError(t, err)
+			require.Equal(t, "v1.1.0", got.ReleaseTag)
+			require.Equal(t, 2, got.ReleasePullRequest)
+			require.Empty(t, got.CreatedTag)
+		}
+		pulls := f.server.Pulls(f.repo)
+		require.Len(t, pulls, 2)
+		require.Equal(t, "Release v1.1.0", pulls[1].Title)
+		require.Equal(t, "release-train/release", pulls[1].Head)
+		require.Equal(t, "main", pulls[1].Base)
+		require.True(t, pulls[1].Open)
+		require.Equal(t, []string{labelNone, labelReleasePR}, pulls[1].Labels)
+		require.True(t, strings.HasPrefix(pulls[1].Body, "Merging this PR releases v1.1.0.\n"))
+
+		commit := f.server.Commit(f.server.Ref(f.repo, "refs/heads/release-train/release"))
+		require.NotNil(t, commit)
+		require.Equal(t, []string{head}, commit.Parents)
+		require.Equal(t, "Release v1.1.0\n\nRelease-Train: release-pr", commit.Message)
+		require.True(t, strings.HasSuffix(pulls[1].Body, "\nRelease-Train: release-pr\n"))
+		require.Contains(t, commit.Files["CHANGELOG.md"], "## [Unreleased]\n\n## [1.1.0] - 2024-01-02\n\n### Features\n\n- add foo")
+
+		// nothing changes locally or in origin
+		require.Equal(t, head, mustRunCmd(t, f.clone, "git", "rev-parse", "HEAD"))
+		require.Empty(t, mustRunCmd(t, f.clone, "git", "ls-remote", "--tags", "origin", "v1.1.0"))
+	})
+
+	t.Run("tags the merged release PR", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		mustRunCmd(t, f.origin, "sh", "-c", `
+printf '# Changelog\n\n## [1.1.0]\n' > CHANGELOG.md
+git commit -q -am "Release v1.1.0 (#2)"
+`)
+		merged := mustRunCmd(t, f.origin, "git", "rev-parse", "HEAD")
+		mustRunCmd(t, f.clone, "git", "pull", "-q")
+		f.repo.Commits = append(f.repo.Commits, merged)
+		f.server.AddPull(f.repo, githubtest.Pull{
+			Number:         2,
+			Title:          "Release v1.1.0",
+			Labels:         []string{labelNone, labelReleasePR},
+			Merged:         true,
+			MergeCommitSha: merged,
+		})
+		got, err := newRunner(t, f).run(t.Context())
+		require.NoError(t, err)
+		require.True(t, got.CreatedTag)
+		require.Zero(t, got.ReleasePullRequest)
+		require.Equal(t, merged, mustRunCmd(t, f.clone, "git", "rev-parse", "v1.1.0^{commit}"))
+		require.Equal(t, merged, mustRunCmd(t, f.origin, "git", "rev-parse", "main"))
+		content, err := os.ReadFile(filepath.Join(f.clone, "CHANGELOG.md"))
+		require.NoError(t, err)
+		require.Equal(t, "# Changelog\n\n## [1.1.0]\n", string(content))
+	})
+
+	t.Run("tags the merged release PR with conventional-commits", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		mustRunCmd(t, f.origin, "sh", "-c", `
+git commit -q --allow-empty -m "feat: add bar"
+printf '# Changelog\n\n## [1.1.0]\n' > CHANGELOG.md
+git commit -q -am "Release v1.1.0 (#2)" -m "Release-Train: release-pr"
+`)
+		merged := mustRunCmd(t, f.origin, "git", "rev-parse", "HEAD")
+		mustRunCmd(t, f.clone, "git", "pull", "-q")
+		runner := newRunner(t, f)
+		runner.LevelSource = levelSourceConventional
+		got, err := runner.run(t.Context())
+		require.NoError(t, err)
+		require.True(t, got.CreatedTag)
+		require.Equal(t, "v1.1.0", got.ReleaseTag)
+		require.Zero(t, got.ReleasePullRequest)
+		require.Equal(t, merged, mustRunCmd(t, f.clone, "git", "rev-parse", "v1.1.0^{commit}"))
+	})
+
+	t.Run("needs GitHub", func(t *testing.T) {
+		t.Parallel()
+		f := setup(t)
+		mustRunCmd(t, f.origin, "git", "commit", "-q", "--allow-empty", "-m", "feat: add bar")
+		mustRunCmd(t, f.clone, "git", "pull", "-q")
+		runner := newRunner(t, f)
+		runner.GithubClient = nil
+		runner.GitOnly = true
+		runner.LevelSource = levelSourceConventional
+		_, err := runner.run(t.Context())
+		require.EqualError(t, err, "--release-pr is only supported with GitHub")
+	})
+}
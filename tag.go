@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// signingFormats are the values git accepts for gpg.format.
+var signingFormats = []string{"openpgp", "ssh", "x509"}
+
+// parseTagMessage parses a --tag-message template. It gets the same data as release notes templates.
+func parseTagMessage(text string) (*template.Template, error) {
+	return template.New("tag-message").Option("missingkey=error").Parse(text)
+}
+
+// validateSigningFormat checks that format is empty or one of signingFormats.
+func validateSigningFormat(format string) error {
+	if format == "" || slices.Contains(signingFormats, format) {
+		return nil
+	}
+	return fmt.Errorf("invalid signing format %q. Valid formats are: %s", format, strings.Join(signingFormats, ", "))
+}
+
+// annotatedTag returns true when release tags are annotated. Signed tags are always annotated.
+func (o *Runner) annotatedTag() bool {
+	return o.TagMessage != nil || o.SignTags
+}
+
+// tagMessage returns the message for an annotated release tag. Without --tag-message, it is the tag name.
+func (o *Runner) tagMessage(result *Result) (string, error) {
+	if o.TagMessage == nil {
+		return result.ReleaseTag, nil
+	}
+	message, err := renderReleaseNotes(o.TagMessage, newReleaseNotesData(o.Repo, result, o.pulls))
+	if err != nil {
+		return "", fmt.Errorf("rendering tag message: %w", err)
+	}
+	return message, nil
+}
+
+// createTag creates the release tag on target in the checkout. The tag is lightweight unless --tag-message or
+// --sign-tags is set.
+func (o *Runner) createTag(ctx context.Context, result *Result, target string) error {
+	if !o.annotatedTag() {
+		_, err := o.runCmd(ctx, nil, "git", "tag", result.ReleaseTag, target)
+		return err
+	}
+	message, err := o.tagMessage(result)
+	if err != nil {
+		return err
+	}
+	messageFile := filepath.Join(o.TempDir, "tag-message")
+	err = os.WriteFile(messageFile, []byte(message+"\n"), 0o600)
+	if err != nil {
+		return err
+	}
+	var args []string
+	if o.SigningFormat != "" {
+		args = append(args, "-c", "gpg.format="+o.SigningFormat)
+	}
+	args = append(args, "tag", "--cleanup=verbatim", "--file="+messageFile)
+	switch {
+	case o.SigningKey != "":
+		args = append(args, "--local-user="+o.SigningKey)
+	case o.SignTags:
+		args = append(args, "--sign")
+	default:
+		args = append(args, "--annotate")
+	}
+	args = append(args, result.ReleaseTag, target)
+	// annotated tags record a tagger
+	env, err := o.commitIdentityEnv(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = o.runCmd(ctx, &runCmdOpts{env: env}, "git", args...)
+	return err
+}
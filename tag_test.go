@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_tagRelease(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (origin, clone string) {
+		t.Helper()
+		return setupTestRepo(t, "v1.0.0", "")
+	}
+
+	newRunner := func(t *testing.T, clone string) *Runner {
+		t.Helper()
+		tagMessage, err := parseTagMessage("Release {{.Tag}}\n{{range .Pulls}}\n- {{.Title}}{{end}}")
+		require.NoError(t, err)
+		runner := newTestRunner(t, clone)
+		runner.TagMessage = tagMessage
+		return runner
+	}
+
+	wantMessage := "Release v1.1.0\n\n- feat: add foo"
+
+	t.Run("lightweight", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t)
+		runner := newRunner(t, clone)
+		runner.TagMessage = nil
+		_, err := runner.run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "commit", mustRunCmd(t, origin, "git", "cat-file", "-t", "v1.1.0"))
+	})
+
+	t.Run("annotated", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t)
+		plan, err := newRunner(t, clone).plan(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, wantMessage, plan.Tag.Message)
+		require.False(t, plan.Tag.Signed)
+
+		_, err = newRunner(t, clone).run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "tag", mustRunCmd(t, origin, "git", "cat-file", "-t", "v1.1.0"))
+		require.Equal(t, wantMessage, mustRunCmd(t, origin, "git", "tag", "--list", "--format=%(contents)", "v1.1.0"))
+	})
+
+	t.Run("annotated without identity", func(t *testing.T) {
+		t.Parallel()
+		_, err := runCmd(t.Context(), nil, "git", "config", "--global", "user.email")
+		if err == nil {
+			t.Skip("a global git identity is configured")
+		}
+		origin, clone := setup(t)
+		mustRunCmd(t, clone, "git", "config", "--unset", "user.name")
+		mustRunCmd(t, clone, "git", "config", "--unset", "user.email")
+		_, err = newRunner(t, clone).run(t.Context())
+		require.NoError(t, err)
+		require.Equal(t,
+			"github-actions[bot] <41898282+github-actions[bot]@users.noreply.github.com>",
+			mustRunCmd(t, origin, "git", "tag", "--list", "--format=%(taggername) %(taggeremail)", "v1.1.0"),
+		)
+	})
+
+	t.Run("ssh signed", func(t *testing.T) {
+		t.Parallel()
+		origin, clone := setup(t)
+		keyDir := t.TempDir()
+		key := filepath.Join(keyDir, "key")
+		mustRunCmd(t, keyDir, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "tester", "-f", key)
+		pub, err := os.ReadFile(key + ".pub")
+		require.NoError(t, err)
+		allowedSigners := filepath.Join(keyDir, "allowed_signers")
+		err = os.WriteFile(allowedSigners, []byte("tester@example.com "+string(pub)), 0o600)
+		require.NoError(t, err)
+
+		runner := newRunner(t, clone)
+		runner.SignTags = true
+		runner.SigningKey = key
+		runner.SigningFormat = "ssh"
+		_, err = runner.run(t.Context())
+		require.NoError(t, err)
+
+		require.True(t, strings.HasPrefix(
+			mustRunCmd(t, origin, "git", "tag", "--list", "--format=%(contents)", "v1.1.0"),
+			wantMessage+"\n-----BEGIN SSH SIGNATURE-----",
+		))
+		mustRunCmd(t, origin, "git", "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "tag", "--verify", "v1.1.0")
+	})
+}
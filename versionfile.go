@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	versionFileRegex = "regex"
+	versionFileJSON  = "json"
+	versionFileTOML  = "toml"
+	versionFileGo    = "go"
+)
+
+// versionFile is a file with a version string that is updated for each release.
+type versionFile struct {
+	Kind string
+	// Path is relative to the checkout directory.
+	Path string
+	// Key is the dotted key path for json and toml files and the constant name for go files.
+	Key     string
+	Pattern *regexp.Regexp
+}
+
+// parseVersionFile parses a --version-file value. The format is KIND:PATH[:ARG] where ARG is a regular expression with
+// a "version" group for regex files, a dotted key path for json and toml files and a constant name for go files.
+func parseVersionFile(spec string) (*versionFile, error) {
+	kind, rest, _ := strings.Cut(spec, ":")
+	filePath, arg, _ := strings.Cut(rest, ":")
+	filePath, ok := checkoutPath(filePath)
+	if !ok {
+		return nil, fmt.Errorf("path %q must be inside the checkout directory", filePath)
+	}
+	f := versionFile{Kind: kind, Path: filePath, Key: arg}
+	switch kind {
+	case versionFileRegex:
+		if arg == "" {
+			return nil, errors.New("regex version files need a regular expression")
+		}
+		var err error
+		f.Pattern, err = regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		if f.Pattern.SubexpIndex("version") < 0 {
+			return nil, errors.New(`the regular expression needs a group named "version"`)
+		}
+		f.Key = ""
+	case versionFileJSON:
+		if f.Key == "" {
+			f.Key = "version"
+		}
+	case versionFileTOML:
+		if f.Key == "" {
+			f.Key = defaultTOMLKey(filePath)
+		}
+		if f.Key == "" {
+			return nil, fmt.Errorf("toml version file %q needs a key", filePath)
+		}
+	case versionFileGo:
+		if f.Key == "" {
+			f.Key = "Version"
+		}
+	default:
+		return nil, fmt.Errorf("unknown kind %q. Valid kinds are: regex, json, toml, go", kind)
+	}
+	return &f, nil
+}
+
+// defaultTOMLKey returns the version key for well-known toml files.
+func defaultTOMLKey(filePath string) string {
+	switch path.Base(filePath) {
+	case "Cargo.toml":
+		return "package.version"
+	case "pyproject.toml":
+		return "project.version"
+	}
+	return ""
+}
+
+// update returns content with the version replaced. Versions that had a "v" prefix keep it, except in regex files
+// where the pattern decides what is replaced.
+func (f *versionFile) update(content, version string) (string, error) {
+	var updated string
+	var err error
+	switch f.Kind {
+	case versionFileRegex:
+		updated, err = replaceRegexVersion(content, f.Pattern, version)
+	case versionFileJSON:
+		updated, err = replaceJSONVersion(content, f.Key, version)
+	case versionFileTOML:
+		updated, err = replaceTOMLVersion(content, f.Key, version)
+	case versionFileGo:
+		updated, err = replaceGoVersion(content, f.Path, f.Key, version)
+	default:
+		err = fmt.Errorf("unknown kind %q", f.Kind)
+	}
+	if err != nil {
+		return "", fmt.Errorf("version file %s: %w", f.Path, err)
+	}
+	return updated, nil
+}
+
+// keepPrefix returns version with a "v" prefix when old has one.
+func keepPrefix(old, version string) string {
+	if strings.HasPrefix(old, "v") {
+		return "v" + version
+	}
+	return version
+}
+
+// splice replaces content[start:end] with s.
+func splice(content string, start, end int, s string) string {
+	return content[:start] + s + content[end:]
+}
+
+// replaceRegexVersion replaces the "version" group of every match of pattern.
+func replaceRegexVersion(content string, pattern *regexp.Regexp, version string) (string, error) {
+	group := pattern.SubexpIndex("version")
+	matches := pattern.FindAllStringSubmatchIndex(content, -1)
+	// replace from the end so that earlier offsets stay valid
+	replaced := false
+	for i := len(matches) - 1; i >= 0; i-- {
+		start, end := matches[i][2*group], matches[i][2*group+1]
+		if start < 0 {
+			continue
+		}
+		content = splice(content, start, end, version)
+		replaced = true
+	}
+	if !replaced {
+		return "", fmt.Errorf("no match for %s", pattern)
+	}
+	return content, nil
+}
+
+// replaceJSONVersion replaces the string at the dotted key path without reformatting the rest of the document.
+func replaceJSONVersion(content, key, version string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	if tok != json.Delim('{') {
+		return "", errors.New("not a json object")
+	}
+	start, end, old, err := findJSONString(dec, content, strings.Split(key, "."))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	value, err := json.Marshal(keepPrefix(old, version))
+	if err != nil {
+		return "", err
+	}
+	return splice(content, start, end, string(value)), nil
+}
+
+// findJSONString finds the string value at keys in the object dec is in and returns its offsets and value.
+func findJSONString(dec *json.Decoder, content string, keys []string) (start, end int, value string, _ error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, 0, "", err
+		}
+		if tok == json.Delim('}') {
+			return 0, 0, "", errors.New("not found")
+		}
+		if tok != keys[0] {
+			err = skipJSONValue(dec)
+			if err != nil {
+				return 0, 0, "", err
+			}
+			continue
+		}
+		if len(keys) > 1 {
+			tok, err = dec.Token()
+			if err != nil {
+				return 0, 0, "", err
+			}
+			if tok != json.Delim('{') {
+				return 0, 0, "", fmt.Errorf("%s isn't an object", keys[0])
+			}
+			return findJSONString(dec, content, keys[1:])
+		}
+		// the offset is after the key, so the value starts at the first quote after the colon
+		keyEnd := int(dec.InputOffset())
+		tok, err = dec.Token()
+		if err != nil {
+			return 0, 0, "", err
+		}
+		value, ok := tok.(string)
+		if !ok {
+			return 0, 0, "", errors.New("not a string")
+		}
+		end = int(dec.InputOffset())
+		start = keyEnd + strings.IndexByte(content[keyEnd:end], '"')
+		return start, end, value, nil
+	}
+}
+
+// skipJSONValue reads the next value from dec.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+var tomlTableRE = regexp.MustCompile(`^\s*\[\[?\s*([^\]]*?)\s*\]\]?\s*(#.*)?$`)
+
+// replaceTOMLVersion replaces the string value of a dotted key. The last part of the key is the key name and the
+// rest is the table it is in, such as "package.version" for the version in Cargo.toml's [package] table. Only keys
+// under a table header or at the top level are found, not inline tables or dotted keys.
+func replaceTOMLVersion(content, key, version string) (string, error) {
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+	keyRE := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(name) + `\s*=\s*(?:"([^"\\]*)"|'([^']*)')`)
+	currentTable := ""
+	offset := 0
+	var scan tomlScanner
+	for _, line := range strings.SplitAfter(content, "\n") {
+		lineStart := offset
+		offset += len(line)
+		// lines continuing a multi-line array, inline table or string aren't headers or keys
+		inValue := scan.inValue()
+		scan.scanLine(line)
+		if inValue {
+			continue
+		}
+		if m := tomlTableRE.FindStringSubmatch(line); m != nil {
+			currentTable = strings.Join(strings.Fields(strings.ReplaceAll(m[1], ".", " . ")), "")
+			continue
+		}
+		if currentTable != table {
+			continue
+		}
+		m := keyRE.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		group := 2
+		if m[group] < 0 {
+			group = 4
+		}
+		start, end := m[group], m[group+1]
+		return splice(content, lineStart+start, lineStart+end, keepPrefix(line[start:end], version)), nil
+	}
+	return "", fmt.Errorf("%s: no string value", key)
+}
+
+// tomlScanner tracks whether a line of TOML starts inside a value that spans lines.
+type tomlScanner struct {
+	depth int    // open brackets and braces
+	quote string // the delimiter of an open multi-line string
+}
+
+func (s *tomlScanner) inValue() bool {
+	return s.depth > 0 || s.quote != ""
+}
+
+// scanLine updates s with the brackets, braces and strings in line. A table header's brackets are scanned like an
+// array's but are always closed on the same line.
+func (s *tomlScanner) scanLine(line string) {
+	for i := 0; i < len(line); i++ {
+		if s.quote != "" {
+			switch {
+			case s.quote == `"""` && line[i] == '\\':
+				i++
+			case strings.HasPrefix(line[i:], s.quote):
+				i += len(s.quote) - 1
+				s.quote = ""
+			}
+			continue
+		}
+		switch c := line[i]; c {
+		case '#':
+			return
+		case '[', '{':
+			s.depth++
+		case ']', '}':
+			s.depth = max(s.depth-1, 0)
+		case '"', '\'':
+			delim := string(c)
+			if strings.HasPrefix(line[i:], strings.Repeat(delim, 3)) {
+				s.quote = strings.Repeat(delim, 3)
+				i += 2
+				continue
+			}
+			// single-line strings end on the same line
+			for i++; i < len(line) && line[i] != c; i++ {
+				if c == '"' && line[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+}
+
+// replaceGoVersion replaces the value of a string constant.
+func replaceGoVersion(content, filename, name, version string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, ident := range valueSpec.Names {
+				if ident.Name != name {
+					continue
+				}
+				if i >= len(valueSpec.Values) {
+					return "", fmt.Errorf("const %s has no value", name)
+				}
+				lit, ok := valueSpec.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return "", fmt.Errorf("const %s isn't a string literal", name)
+				}
+				old, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return "", err
+				}
+				start := fset.Position(lit.Pos()).Offset
+				end := fset.Position(lit.End()).Offset
+				return splice(content, start, end, strconv.Quote(keepPrefix(old, version))), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("const %s not found", name)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseVersionFile(t *testing.T) {
+	t.Parallel()
+	for _, td := range []struct {
+		spec    string
+		want    versionFile
+		wantErr string
+	}{
+		{spec: "json:package.json", want: versionFile{Kind: "json", Path: "package.json", Key: "version"}},
+		{spec: "json:./web/../package.json:meta.version", want: versionFile{Kind: "json", Path: "package.json", Key: "meta.version"}},
+		{spec: "toml:crates/foo/Cargo.toml", want: versionFile{Kind: "toml", Path: "crates/foo/Cargo.toml", Key: "package.version"}},
+		{spec: "toml:pyproject.toml", want: versionFile{Kind: "toml", Path: "pyproject.toml", Key: "project.version"}},
+		{spec: "toml:other.toml", wantErr: `toml version file "other.toml" needs a key`},
+		{spec: "go:version.go", want: versionFile{Kind: "go", Path: "version.go", Key: "Version"}},
+		{spec: "go:version.go:AppVersion", want: versionFile{Kind: "go", Path: "version.go", Key: "AppVersion"}},
+		{spec: "regex:README.md", wantErr: "regex version files need a regular expression"},
+		{spec: "regex:README.md:v\\d+", wantErr: `the regular expression needs a group named "version"`},
+		{spec: "regex:README.md:(", wantErr: "error parsing regexp: missing closing ): `(`"},
+		{spec: "yaml:foo.yaml", wantErr: `unknown kind "yaml". Valid kinds are: regex, json, toml, go`},
+		{spec: "json:../package.json", wantErr: `path "../package.json" must be inside the checkout directory`},
+	} {
+		t.Run(td.spec, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseVersionFile(td.spec)
+			if td.wantErr != "" {
+				require.EqualError(t, err, td.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &td.want, got)
+		})
+	}
+
+	t.Run("regex", func(t *testing.T) {
+		t.Parallel()
+		got, err := parseVersionFile("regex:README.md:uses: foo/bar@v(?P<version>[^ ]+)")
+		require.NoError(t, err)
+		require.Equal(t, "README.md", got.Path)
+		require.Equal(t, "uses: foo/bar@v(?P<version>[^ ]+)", got.Pattern.String())
+	})
+}
+
+func Test_versionFile_update(t *testing.T) {
+	t.Parallel()
+	for _, td := range []struct {
+		name    string
+		spec    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "regex",
+			spec:    `regex:README.md:foo/bar@v(?P<version>\S+)`,
+			content: "uses: foo/bar@v1.0.0\nuses: foo/bar@v1.0.0 # again\n",
+			want:    "uses: foo/bar@v1.2.3\nuses: foo/bar@v1.2.3 # again\n",
+		},
+		{
+			name:    "regex no match",
+			spec:    `regex:README.md:baz@(?P<version>\S+)`,
+			content: "uses: foo/bar@v1.0.0\n",
+			wantErr: `version file README.md: no match for baz@(?P<version>\S+)`,
+		},
+		{
+			name: "json",
+			spec: "json:package.json",
+			content: `{
+  "name": "foo",
+  "scripts": {"version": "echo"},
+  "files": ["a", {"b": [1, 2]}],
+  "version" :  "1.0.0",
+  "private": true
+}
+`,
+			want: `{
+  "name": "foo",
+  "scripts": {"version": "echo"},
+  "files": ["a", {"b": [1, 2]}],
+  "version" :  "1.2.3",
+  "private": true
+}
+`,
+		},
+		{
+			name:    "json nested",
+			spec:    "json:meta.json:meta.version",
+			content: `{"version": "0.1.0", "meta": {"name": "x", "version": "v1.0.0"}}`,
+			want:    `{"version": "0.1.0", "meta": {"name": "x", "version": "v1.2.3"}}`,
+		},
+		{
+			name:    "json missing",
+			spec:    "json:package.json",
+			content: `{"name": "foo"}`,
+			wantErr: "version file package.json: version: not found",
+		},
+		{
+			name:    "json not a string",
+			spec:    "json:package.json",
+			content: `{"version": 1}`,
+			wantErr: "version file package.json: version: not a string",
+		},
+		{
+			name: "toml",
+			spec: "toml:Cargo.toml",
+			content: `version = "0.0.1"
+
+[package]
+name = "foo"
+version = "1.0.0" # the version
+
+[dependencies]
+version = "2.0.0"
+`,
+			want: `version = "0.0.1"
+
+[package]
+name = "foo"
+version = "1.2.3" # the version
+
+[dependencies]
+version = "2.0.0"
+`,
+		},
+		{
+			name:    "toml nested table",
+			spec:    "toml:foo.toml:tool.poetry.version",
+			content: "[ tool . poetry ]\nversion = 'v1.0.0'\n",
+			want:    "[ tool . poetry ]\nversion = 'v1.2.3'\n",
+		},
+		{
+			name:    "toml top level",
+			spec:    "toml:foo.toml:version",
+			content: "version = \"1.0.0\"\n[package]\nversion = \"1.0.0\"\n",
+			want:    "version = \"1.2.3\"\n[package]\nversion = \"1.0.0\"\n",
+		},
+		{
+			name: "toml multi-line values",
+			spec: "toml:pyproject.toml",
+			content: `[project]
+classifiers = [
+  ["a"],
+  "b # ]",
+]
+description = """
+[tool]
+version = "0.0.1"
+"""
+version = "1.0.0"
+`,
+			want: `[project]
+classifiers = [
+  ["a"],
+  "b # ]",
+]
+description = """
+[tool]
+version = "0.0.1"
+"""
+version = "1.2.3"
+`,
+		},
+		{
+			name:    "toml workspace version",
+			spec:    "toml:Cargo.toml",
+			content: "[package]\nversion.workspace = true\n",
+			wantErr: "version file Cargo.toml: package.version: no string value",
+		},
+		{
+			name: "go",
+			spec: "go:internal/version.go",
+			content: `package version
+
+const (
+	Name    = "foo"
+	Version = "v1.0.0" // the version
+)
+`,
+			want: `package version
+
+const (
+	Name    = "foo"
+	Version = "v1.2.3" // the version
+)
+`,
+		},
+		{
+			name:    "go raw string",
+			spec:    "go:version.go",
+			content: "package main\n\nconst Version = `1.0.0`\n",
+			want:    "package main\n\nconst Version = \"1.2.3\"\n",
+		},
+		{
+			name:    "go var",
+			spec:    "go:version.go",
+			content: "package main\n\nvar Version = \"1.0.0\"\n",
+			wantErr: "version file version.go: const Version not found",
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			t.Parallel()
+			file, err := parseVersionFile(td.spec)
+			require.NoError(t, err)
+			got, err := file.update(td.content, "1.2.3")
+			if td.wantErr != "" {
+				require.EqualError(t, err, td.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.want, got)
+		})
+	}
+}
+
+func TestRunner_versionFiles(t *testing.T) {
+	t.Parallel()
+	origin, clone := setupTestRepo(t, "v1.0.0", `
+git config receive.denyCurrentBranch ignore
+printf '{\n  "version": "1.0.0"\n}\n' > package.json
+printf 'package main\n\nconst Version = "v1.1.0"\n' > version.go
+printf 'foo@v1.0.0\n' > README.md
+git add .
+git commit -q -m "add version files"
+`)
+	head := mustRunCmd(t, clone, "git", "rev-parse", "HEAD")
+	var files []*versionFile
+	for _, spec := range []string{
+		"json:package.json",
+		"go:version.go",
+		`regex:README.md:foo@v(?P<version>\S+)`,
+		`regex:README.md:(?P<version>foo)`,
+	} {
+		file, err := parseVersionFile(spec)
+		require.NoError(t, err)
+		files = append(files, file)
+	}
+	runner := newTestRunner(t, clone)
+	runner.VersionFiles = files
+	got, err := runner.run(t.Context())
+	require.NoError(t, err)
+	require.True(t, got.CreatedTag)
+	require.Equal(t, head, mustRunCmd(t, origin, "git", "rev-parse", "v1.1.0^"))
+	require.Equal(t, mustRunCmd(t, origin, "git", "rev-parse", "v1.1.0^{commit}"), mustRunCmd(t, origin, "git", "rev-parse", "main"))
+	// version.go already had the version, so only the other files changed
+	require.Equal(t, "README.md\npackage.json", mustRunCmd(t, origin, "git", "diff", "--name-only", "v1.1.0^", "v1.1.0"))
+	require.Equal(t, "{\n  \"version\": \"1.1.0\"\n}", mustRunCmd(t, origin, "git", "show", "v1.1.0:package.json"))
+	require.Equal(t, "1.1.0@v1.1.0", mustRunCmd(t, origin, "git", "show", "v1.1.0:README.md"))
+}